@@ -0,0 +1,523 @@
+package papertool
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+ * Backups are archives of a server directory named
+ *
+ *   papertool-backup-20261018T041500.000Z.tar.zst   (or .zip)
+ *
+ * so they sort by age and Prune can find them. The first entry,
+ * papertool-backup.json, is a BackupManifest saying what was installed
+ * when the backup was taken. For zip archives the manifest is also the
+ * archive comment.
+ *
+ * Nothing is locked while the archive is written, so for a consistent
+ * world the server should be stopped (or have saving turned off) first.
+ */
+
+type BackupFormat string
+
+const (
+	BackupTarZst BackupFormat = "tar.zst"
+	BackupZip    BackupFormat = "zip"
+
+	backupPrefix       = "papertool-backup-"
+	backupTimeFormat   = "20060102T150405.000Z"
+	BackupManifestName = "papertool-backup.json"
+)
+
+// BackupManifest is stored in every backup.
+type BackupManifest struct {
+	CreatedAt time.Time      `json:"created_at"`
+	Dir       string         `json:"dir"`
+	Install   *InstallRecord `json:"install,omitempty"` // nil if nothing could be found
+
+	// InstallSource says where Install came from: "install" for the
+	// record init writes, otherwise "history" for the last install
+	// history entry or "jar" for a server jar found in dir, in which case
+	// only the project, version, build and jar are known.
+	InstallSource string `json:"install_source,omitempty"`
+}
+
+type BackupOptions struct {
+	Format BackupFormat
+
+	// DstDir is where the archive is written. Defaults to backups/ in
+	// the server directory, which is then left out of the archive.
+	DstDir string
+
+	// Include and Exclude are globs, as for path.Match, matched against
+	// slash separated paths relative to the server directory. A pattern
+	// matching a directory matches everything under it, and patterns
+	// without a slash also match base names, so "*.log" matches
+	// logs/latest.log. An empty Include means everything.
+	Include []string
+	Exclude []string
+
+	// Now is when the backup is taken. Defaults to time.Now().
+	Now time.Time
+}
+
+func DefaultBackupOptions() *BackupOptions {
+	return &BackupOptions{
+		Format: BackupTarZst,
+	}
+}
+
+// Backup archives dir according to opts and returns the archive's path.
+func Backup(dir string, opts *BackupOptions) (string, error) {
+	format := opts.Format
+	if format == "" {
+		format = BackupTarZst
+	}
+	if format != BackupTarZst && format != BackupZip {
+		return "", fmt.Errorf("unknown backup format '%s', must be %s or %s", format, BackupTarZst, BackupZip)
+	}
+
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+
+	dstdir := opts.DstDir
+	if dstdir == "" {
+		dstdir = filepath.Join(dir, "backups")
+	}
+
+	err := os.MkdirAll(dstdir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	manifest := &BackupManifest{
+		CreatedAt: now,
+		Dir:       dir,
+	}
+	manifest.Install, manifest.InstallSource, err = backupInstall(dir)
+	if err != nil {
+		return "", err
+	}
+
+	rawManifest, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	files, err := backupFiles(dir, dstdir, opts)
+	if err != nil {
+		return "", err
+	}
+
+	dst := filepath.Join(dstdir, backupPrefix+now.Format(backupTimeFormat)+"."+string(format))
+	tmp := dst + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case BackupTarZst:
+		err = writeTarZst(f, dir, files, rawManifest)
+	case BackupZip:
+		err = writeZip(f, dir, files, rawManifest)
+	}
+
+	cerr := f.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("%s: %v", dst, err)
+	}
+
+	err = os.Rename(tmp, dst)
+	if err != nil {
+		return "", err
+	}
+
+	return dst, nil
+}
+
+// backupInstall works out what's installed in dir for the manifest. The
+// install record is best, but directories that weren't set up by init may
+// still have an install history, or at least a server jar whose name says
+// what it is.
+func backupInstall(dir string) (*InstallRecord, string, error) {
+	rec, err := ReadInstallRecord(dir)
+	if err == nil {
+		return rec, "install", nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, "", err
+	}
+
+	history, err := ReadHistory(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(history) > 0 {
+		e := history[len(history)-1]
+		rec := &InstallRecord{
+			Project:     e.Project,
+			Version:     e.Version,
+			Build:       e.Build,
+			Jar:         e.Artifact,
+			InstalledAt: e.Time,
+		}
+		if e.Sha256 != "" {
+			rec.Checksums = Checksums{"sha256": e.Sha256}
+		}
+		return rec, "history", nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.jar"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// If there's more than one, the most recently written is most likely
+	// the one in use.
+	var newest os.FileInfo
+	for _, m := range matches {
+		_, _, _, ok := ParseArtifactName(m)
+		if !ok {
+			continue
+		}
+		st, err := os.Stat(m)
+		if err != nil {
+			continue
+		}
+		if newest == nil || st.ModTime().After(newest.ModTime()) {
+			newest = st
+		}
+	}
+	if newest == nil {
+		return nil, "", nil
+	}
+
+	project, version, build, _ := ParseArtifactName(newest.Name())
+
+	return &InstallRecord{
+		Project: project,
+		Version: version,
+		Build:   build,
+		Jar:     newest.Name(),
+	}, "jar", nil
+}
+
+// backupFiles returns the slash separated paths, relative to dir, of
+// everything to archive, skipping dstdir.
+func backupFiles(dir string, dstdir string, opts *BackupOptions) ([]string, error) {
+	skip, err := filepath.Abs(dstdir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				return err
+			}
+			if abs == skip {
+				return filepath.SkipDir
+			}
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if globMatch(opts.Exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		if len(opts.Include) > 0 && !globMatch(opts.Include, rel) {
+			return nil
+		}
+
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// globMatch reports whether rel, or any directory containing it, matches
+// one of patterns.
+func globMatch(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+
+		for p := rel; p != "." && p != "/"; p = path.Dir(p) {
+			name := p
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(p)
+			}
+			ok, _ := path.Match(pattern, name)
+			if ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func writeTarZst(w io.Writer, dir string, files []string, manifest []byte) error {
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+
+	err = tw.WriteHeader(&tar.Header{
+		Name:     BackupManifestName,
+		Mode:     0644,
+		Size:     int64(len(manifest)),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	})
+	if err == nil {
+		_, err = tw.Write(manifest)
+	}
+
+	for _, rel := range files {
+		if err != nil {
+			break
+		}
+		err = addTarFile(tw, dir, rel)
+	}
+
+	if err == nil {
+		err = tw.Close()
+	}
+	cerr := zw.Close()
+	if err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+func addTarFile(tw *tar.Writer, dir string, rel string) error {
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+
+	st, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+
+	link := ""
+	if st.Mode()&os.ModeSymlink != 0 {
+		link, err = os.Readlink(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(st, link)
+	if err != nil {
+		return err
+	}
+	hdr.Name = rel
+
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
+
+	if !st.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.CopyN(tw, f, hdr.Size)
+	return err
+}
+
+func writeZip(w io.Writer, dir string, files []string, manifest []byte) error {
+	zw := zip.NewWriter(w)
+
+	err := zw.SetComment(string(manifest))
+	if err != nil {
+		return err
+	}
+
+	mw, err := zw.Create(BackupManifestName)
+	if err == nil {
+		_, err = mw.Write(manifest)
+	}
+
+	for _, rel := range files {
+		if err != nil {
+			break
+		}
+		err = addZipFile(zw, dir, rel)
+	}
+
+	cerr := zw.Close()
+	if err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+func addZipFile(zw *zip.Writer, dir string, rel string) error {
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+
+	st, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+
+	// zip has no portable symlinks; skip them and anything else special.
+	if !st.Mode().IsRegular() {
+		return nil
+	}
+
+	hdr, err := zip.FileInfoHeader(st)
+	if err != nil {
+		return err
+	}
+	hdr.Name = rel
+	hdr.Method = zip.Deflate
+
+	fw, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(fw, f)
+	return err
+}
+
+// BackupInfo is a backup found by ListBackups.
+type BackupInfo struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+// ListBackups returns the backups in dstdir, oldest first.
+func ListBackups(dstdir string) ([]*BackupInfo, error) {
+	entries, err := os.ReadDir(dstdir)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []*BackupInfo{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, backupPrefix) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(name, backupPrefix)
+		switch {
+		case strings.HasSuffix(stamp, "."+string(BackupTarZst)):
+			stamp = strings.TrimSuffix(stamp, "."+string(BackupTarZst))
+		case strings.HasSuffix(stamp, "."+string(BackupZip)):
+			stamp = strings.TrimSuffix(stamp, "."+string(BackupZip))
+		default:
+			continue
+		}
+
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, &BackupInfo{
+			Path:      filepath.Join(dstdir, name),
+			CreatedAt: t,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.Before(backups[j].CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// PruneBackups removes backups in dstdir beyond the newest keep, and
+// those older than maxAge. Zero means no limit for either. The newest
+// backup is never removed. It returns the paths removed.
+func PruneBackups(dstdir string, keep int, maxAge time.Duration, now time.Time) ([]string, error) {
+	backups, err := ListBackups(dstdir)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for i, b := range backups {
+		newer := len(backups) - 1 - i
+		if newer == 0 {
+			break
+		}
+
+		tooMany := keep > 0 && newer >= keep
+		tooOld := maxAge > 0 && now.Sub(b.CreatedAt) > maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		err = os.Remove(b.Path)
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, b.Path)
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,346 @@
+package papertool
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"encoding/json"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		rel      string
+		want     bool
+	}{
+		{[]string{"*.log"}, "latest.log", true},
+		{[]string{"*.log"}, "logs/latest.log", true},
+		{[]string{"logs"}, "logs/2026-05-10-1.log.gz", true},
+		{[]string{"logs/"}, "logs/latest.log", true},
+		{[]string{"world"}, "world_nether/level.dat", false},
+		{[]string{"world*"}, "world_nether/level.dat", true},
+		{[]string{"world/region"}, "world/region/r.0.0.mca", true},
+		{[]string{"world/region"}, "world_nether/region/r.0.0.mca", false},
+		{[]string{"plugins/*.jar"}, "plugins/Example.jar", true},
+		{[]string{"plugins/*.jar"}, "plugins/Example/config.yml", false},
+		{[]string{"cache", "*.tmp"}, "cache/x", true},
+		{[]string{}, "server.properties", false},
+	}
+
+	for _, test := range tests {
+		got := globMatch(test.patterns, test.rel)
+		if got != test.want {
+			t.Fatalf("%q %s: expected %v got %v", test.patterns, test.rel, test.want, got)
+		}
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		keep   int
+		maxAge time.Duration
+		left   []int // indexes into ages of the backups that should remain
+	}{
+		{0, 0, []int{0, 1, 2, 3, 4}},
+		{2, 0, []int{3, 4}},
+		{10, 0, []int{0, 1, 2, 3, 4}},
+		{0, 36 * time.Hour, []int{2, 3, 4}},
+		{2, 36 * time.Hour, []int{3, 4}},
+		{4, 36 * time.Hour, []int{2, 3, 4}},
+		// Everything's too old, but the newest is always kept.
+		{0, time.Minute, []int{4}},
+	}
+
+	// Oldest first; the same second twice to check the milliseconds sort.
+	ages := []time.Duration{
+		72 * time.Hour,
+		48 * time.Hour,
+		24*time.Hour + 500*time.Millisecond,
+		24 * time.Hour,
+		time.Hour,
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+
+		paths := []string{}
+		for i, age := range ages {
+			format := BackupTarZst
+			if i%2 == 1 {
+				format = BackupZip
+			}
+			p := filepath.Join(dir, backupPrefix+now.Add(-age).Format(backupTimeFormat)+"."+string(format))
+			err := os.WriteFile(p, []byte("x"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			paths = append(paths, p)
+		}
+		// Not backups, so never touched.
+		for _, name := range []string{"notes.txt", backupPrefix + "garbage.tar.zst"} {
+			err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		_, err := PruneBackups(dir, test.keep, test.maxAge, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		backups, err := ListBackups(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		left := []string{}
+		for _, b := range backups {
+			left = append(left, b.Path)
+		}
+		want := []string{}
+		for _, i := range test.left {
+			want = append(want, paths[i])
+		}
+		if !reflect.DeepEqual(left, want) {
+			t.Fatalf("keep %d max age %v: expected %q got %q", test.keep, test.maxAge, want, left)
+		}
+
+		_, err = os.Stat(filepath.Join(dir, "notes.txt"))
+		if err != nil {
+			t.Fatalf("keep %d max age %v: removed notes.txt", test.keep, test.maxAge)
+		}
+	}
+}
+
+// testServerDir makes a small server directory to back up.
+func testServerDir(t *testing.T) (string, map[string]string) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"paper-1.21.4-232.jar":   "jar",
+		"server.properties":      "motd=hi\n",
+		"world/level.dat":        "level",
+		"world/region/r.0.0.mca": "region",
+		"logs/latest.log":        "log",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(p), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(p, []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir, files
+}
+
+// readBackup returns the files in a backup archive, and its manifest.
+func readBackup(t *testing.T, path string) (map[string]string, *BackupManifest) {
+	files := map[string]string{}
+
+	switch filepath.Ext(path) {
+	case ".zst":
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+
+		tr := tar.NewReader(zr)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[hdr.Name] = string(raw)
+		}
+
+	case ".zip":
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+
+		for _, zf := range zr.File {
+			r, err := zf.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, err := io.ReadAll(r)
+			r.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[zf.Name] = string(raw)
+		}
+		if zr.Comment != files[BackupManifestName] {
+			t.Fatalf("%s: comment doesn't match the manifest", path)
+		}
+
+	default:
+		t.Fatalf("%s: unknown format", path)
+	}
+
+	manifest := &BackupManifest{}
+	err := json.Unmarshal([]byte(files[BackupManifestName]), manifest)
+	if err != nil {
+		t.Fatalf("%s: manifest: %v", path, err)
+	}
+	delete(files, BackupManifestName)
+
+	return files, manifest
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	for _, format := range []BackupFormat{BackupTarZst, BackupZip} {
+		dir, files := testServerDir(t)
+
+		rec := &InstallRecord{Project: "paper", Version: "1.21.4", Build: "232", Jar: "paper-1.21.4-232.jar"}
+		err := WriteInstallRecord(dir, rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[".papertool/install.json"], err = readString(InstallRecordPath(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts := DefaultBackupOptions()
+		opts.Format = format
+		opts.Now = now
+
+		path, err := Backup(dir, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if filepath.Dir(path) != filepath.Join(dir, "backups") {
+			t.Fatalf("%s: backup written to %s", format, path)
+		}
+
+		// A second backup mustn't include the first.
+		opts.Now = now.Add(time.Hour)
+		path, err = Backup(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, manifest := readBackup(t, path)
+		if !reflect.DeepEqual(got, files) {
+			t.Fatalf("%s: expected %v got %v", format, keys(files), keys(got))
+		}
+		if !manifest.CreatedAt.Equal(now.Add(time.Hour)) || manifest.InstallSource != "install" || manifest.Install == nil || manifest.Install.Build != "232" {
+			t.Fatalf("%s: bad manifest %+v", format, manifest)
+		}
+	}
+}
+
+func TestBackupIncludeExclude(t *testing.T) {
+	dir, _ := testServerDir(t)
+
+	opts := DefaultBackupOptions()
+	opts.DstDir = t.TempDir()
+	opts.Include = []string{"world", "server.properties"}
+	opts.Exclude = []string{"region"}
+
+	path, err := Backup(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := readBackup(t, path)
+	want := []string{"server.properties", "world/level.dat"}
+	if !reflect.DeepEqual(keys(got), want) {
+		t.Fatalf("expected %q got %q", want, keys(got))
+	}
+}
+
+func TestBackupManifestFallback(t *testing.T) {
+	// Only a jar to go on.
+	dir, _ := testServerDir(t)
+	opts := DefaultBackupOptions()
+	opts.DstDir = t.TempDir()
+
+	path, err := Backup(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, manifest := readBackup(t, path)
+	if manifest.InstallSource != "jar" || manifest.Install == nil ||
+		manifest.Install.Project != "paper" || manifest.Install.Version != "1.21.4" || manifest.Install.Build != "232" {
+		t.Fatalf("jar: bad manifest %+v %+v", manifest, manifest.Install)
+	}
+
+	// The install history beats the jar name.
+	err = AppendHistory(dir, &HistoryEntry{Project: "paper", Version: "1.21.4", Build: "233", Artifact: "paper-1.21.4-233.jar", Sha256: "aaaa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Now = time.Now().Add(time.Second)
+	path, err = Backup(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, manifest = readBackup(t, path)
+	if manifest.InstallSource != "history" || manifest.Install.Build != "233" || manifest.Install.Checksums["sha256"] != "aaaa" {
+		t.Fatalf("history: bad manifest %+v %+v", manifest, manifest.Install)
+	}
+
+	// Nothing at all.
+	empty := t.TempDir()
+	err = os.WriteFile(filepath.Join(empty, "server.properties"), []byte("x"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err = Backup(empty, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, manifest = readBackup(t, path)
+	if manifest.Install != nil || manifest.InstallSource != "" {
+		t.Fatalf("empty: bad manifest %+v", manifest)
+	}
+}
+
+func readString(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	return string(raw), err
+}
+
+func keys(m map[string]string) []string {
+	k := make([]string, 0, len(m))
+	for name := range m {
+		k = append(k, name)
+	}
+	sort.Strings(k)
+
+	return k
+}
@@ -0,0 +1,125 @@
+package papertool
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DownloadTarget identifies a single artifact to fetch as part of a batch.
+type DownloadTarget struct {
+	Project  string
+	Version  string
+	Build    string
+	Artifact *Artifact
+}
+
+func (t *DownloadTarget) String() string {
+	return fmt.Sprintf("%s %s build %s", t.Project, t.Version, t.Build)
+}
+
+// BatchOptions configures DownloadBatch. The embedded DownloadOptions
+// apply to every item; a single ProgressReporter is shared by all of them.
+type BatchOptions struct {
+	DownloadOptions
+	DstDir  string
+	Workers int // number of concurrent downloads; <= 0 means 1
+}
+
+// TargetError is the error for one failed item in a batch.
+type TargetError struct {
+	Target *DownloadTarget
+	Err    error
+}
+
+func (e *TargetError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Target, e.Err)
+}
+
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned by DownloadBatch when one or more items failed.
+// Items that aren't listed were downloaded successfully.
+type BatchError struct {
+	Total  int
+	Failed []*TargetError
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		msgs = append(msgs, f.Error())
+	}
+
+	return fmt.Sprintf("%d of %d downloads failed:\n\t%s", len(e.Failed), e.Total, strings.Join(msgs, "\n\t"))
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		errs = append(errs, f)
+	}
+
+	return errs
+}
+
+// DownloadBatch downloads every target into opts.DstDir using a pool of
+// opts.Workers concurrent downloads. All targets are attempted even if
+// some fail; failures are reported together in a *BatchError.
+func DownloadBatch(serverURL *url.URL, targets []*DownloadTarget, opts *BatchOptions) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(targets) {
+		workers = len(targets)
+	}
+
+	progress := opts.progress()
+	errs := make([]error, len(targets))
+
+	work := make(chan int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range work {
+				errs[j] = downloadTarget(serverURL, targets[j], opts, progress)
+			}
+		}()
+	}
+
+	for i := range targets {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	berr := &BatchError{
+		Total: len(targets),
+	}
+	for i, err := range errs {
+		if err != nil {
+			berr.Failed = append(berr.Failed, &TargetError{Target: targets[i], Err: err})
+		}
+	}
+
+	if len(berr.Failed) > 0 {
+		return berr
+	}
+
+	return nil
+}
+
+func downloadTarget(serverURL *url.URL, t *DownloadTarget, opts *BatchOptions, progress ProgressReporter) error {
+	// Every item gets its own name in DstDir; a single Output path makes
+	// no sense for a batch.
+	dopts := opts.DownloadOptions
+	dopts.Output = ""
+
+	return downloadBuild(serverURL, t.Project, t.Version, t.Build, t.Artifact, opts.DstDir, &dopts, progress)
+}
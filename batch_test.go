@@ -0,0 +1,154 @@
+package papertool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchTarget returns a target for /jar/name on server carrying jar's
+// sha256, or sha if that's set.
+func batchTarget(server string, build string, jar []byte, sha string) *DownloadTarget {
+	if sha == "" {
+		sum := sha256.Sum256(jar)
+		sha = hex.EncodeToString(sum[:])
+	}
+
+	name := fmt.Sprintf("paper-1.21.4-%s.jar", build)
+	src := server + "/jar/" + name
+	size := int64(len(jar))
+
+	return &DownloadTarget{
+		Project: "paper",
+		Version: "1.21.4",
+		Build:   build,
+		Artifact: &Artifact{
+			Application: &Application{Name: &name, URL: &src, Sha256: &sha, Size: &size, Checksums: Checksums{"sha256": sha}},
+		},
+	}
+}
+
+func TestDownloadBatchPartialFailure(t *testing.T) {
+	jar := []byte("not really a jar")
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/jar/": serveJar(jar),
+	})
+
+	bad := batchTarget(server.String(), "2", jar, hex.EncodeToString(make([]byte, sha256.Size)))
+	noName := batchTarget(server.String(), "4", jar, "")
+	noName.Artifact.Application.Name = nil
+
+	targets := []*DownloadTarget{
+		batchTarget(server.String(), "1", jar, ""),
+		bad,
+		batchTarget(server.String(), "3", jar, ""),
+		noName,
+	}
+
+	dir := t.TempDir()
+	opts := &BatchOptions{DstDir: dir, Workers: 3}
+	opts.Progress = NopProgress{}
+
+	err := DownloadBatch(server, targets, opts)
+
+	var berr *BatchError
+	if !errors.As(err, &berr) {
+		t.Fatalf("1: expected *BatchError got %v", err)
+	}
+	if berr.Total != 4 || len(berr.Failed) != 2 || berr.Failed[0].Target != bad || berr.Failed[1].Target != noName {
+		t.Fatalf("2: bad failures %v", berr)
+	}
+
+	// The individual failures can be reached through the batch.
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Algorithm != "sha256" {
+		t.Fatalf("3: expected *ChecksumMismatchError got %v", err)
+	}
+	if !errors.Is(err, berr.Failed[0].Err) {
+		t.Fatalf("4: errors.Is didn't find the item's error")
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Fatalf("5: nothing was not found")
+	}
+	var te *TargetError
+	if !errors.As(err, &te) || te.Target != bad {
+		t.Fatalf("6: expected the first *TargetError")
+	}
+
+	// The good ones were still downloaded.
+	for _, build := range []string{"1", "3"} {
+		_, err := os.Stat(filepath.Join(dir, "paper-1.21.4-"+build+".jar"))
+		if err != nil {
+			t.Fatalf("7: build %s: %v", build, err)
+		}
+	}
+
+	// All good is no error at all.
+	err = DownloadBatch(server, targets[:1], &BatchOptions{DstDir: t.TempDir(), DownloadOptions: DownloadOptions{Progress: NopProgress{}}})
+	if err != nil {
+		t.Fatalf("8: %v", err)
+	}
+}
+
+func TestDownloadBatchWorkers(t *testing.T) {
+	jar := []byte("not really a jar")
+
+	mu := &sync.Mutex{}
+	running := 0
+	most := 0
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/jar/": func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				mu.Lock()
+				running++
+				if running > most {
+					most = running
+				}
+				mu.Unlock()
+
+				time.Sleep(50 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+			}
+			serveJar(jar)(w, r)
+		},
+	})
+
+	targets := []*DownloadTarget{}
+	for i := 1; i <= 8; i++ {
+		targets = append(targets, batchTarget(server.String(), fmt.Sprint(i), jar, ""))
+	}
+
+	for _, workers := range []int{1, 3} {
+		mu.Lock()
+		most = 0
+		mu.Unlock()
+
+		opts := &BatchOptions{DstDir: t.TempDir(), Workers: workers}
+		opts.Progress = NopProgress{}
+
+		err := DownloadBatch(server, targets, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mu.Lock()
+		got := most
+		mu.Unlock()
+		if got > workers {
+			t.Fatalf("%d workers: %d downloads at once", workers, got)
+		}
+		if workers > 1 && got < 2 {
+			t.Fatalf("%d workers: downloads didn't overlap", workers)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package papertool
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Checksums maps an algorithm name, as used in the v3 "checksums" object
+// ("sha256", "sha512", ...), to a hex encoded digest.
+type Checksums map[string]string
+
+// checksumAlgorithms are the algorithms papertool can compute. md5 and
+// sha1 are only here for upstreams that publish nothing better.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"sha1":   sha1.New,
+	"md5":    md5.New,
+}
+
+// KnownChecksumAlgorithms returns the names of the algorithms papertool
+// can verify, sorted.
+func KnownChecksumAlgorithms() []string {
+	names := make([]string, 0, len(checksumAlgorithms))
+	for name := range checksumAlgorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ChecksumMismatchError reports a digest that didn't match.
+type ChecksumMismatchError struct {
+	Name      string
+	Algorithm string
+	Got       string
+	Expected  string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s: %s mismatch %s expected %s", e.Name, e.Algorithm, e.Got, e.Expected)
+}
+
+// multiHash computes every known algorithm in a single pass.
+type multiHash map[string]hash.Hash
+
+func newMultiHash() multiHash {
+	m := multiHash{}
+	for name, h := range checksumAlgorithms {
+		m[name] = h()
+	}
+
+	return m
+}
+
+func (m multiHash) Write(data []byte) (int, error) {
+	for _, h := range m {
+		h.Write(data)
+	}
+
+	return len(data), nil
+}
+
+func (m multiHash) Sums() Checksums {
+	sums := Checksums{}
+	for name, h := range m {
+		sums[name] = hexSum(h)
+	}
+
+	return sums
+}
+
+// Known returns only the entries papertool knows how to compute.
+func (c Checksums) Known() Checksums {
+	known := Checksums{}
+	for name, sum := range c {
+		if _, ok := checksumAlgorithms[name]; ok && sum != "" {
+			known[name] = strings.ToLower(sum)
+		}
+	}
+
+	return known
+}
+
+// Verify compares every known algorithm in expected against actual,
+// returning a *ChecksumMismatchError for the first that differs. name is
+// only used in the error.
+func (c Checksums) Verify(name string, actual Checksums) error {
+	expected := c.Known()
+
+	algs := make([]string, 0, len(expected))
+	for alg := range expected {
+		algs = append(algs, alg)
+	}
+	sort.Strings(algs)
+
+	for _, alg := range algs {
+		if actual[alg] != expected[alg] {
+			return &ChecksumMismatchError{
+				Name:      name,
+				Algorithm: alg,
+				Got:       actual[alg],
+				Expected:  expected[alg],
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExpectedChecksums returns every checksum published for the application,
+// including the legacy Sha256 field.
+func (a *Application) ExpectedChecksums() Checksums {
+	sums := Checksums{}
+	for name, sum := range a.Checksums {
+		sums[name] = sum
+	}
+
+	if a.Sha256 != nil && *a.Sha256 != "" {
+		sums["sha256"] = *a.Sha256
+	}
+
+	return sums
+}
+
+// HashFileChecksums returns every known checksum of the file at path.
+func HashFileChecksums(path string) (Checksums, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := newMultiHash()
+	_, err = io.Copy(m, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return m.Sums(), nil
+}
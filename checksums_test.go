@@ -0,0 +1,36 @@
+package papertool
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChecksumsVerify(t *testing.T) {
+	m := newMultiHash()
+	m.Write([]byte("hello"))
+	actual := m.Sums()
+
+	if actual["md5"] != "5d41402abc4b2a76b9719d911017c592" {
+		t.Fatalf("1: bad md5 %s", actual["md5"])
+	}
+	if actual["sha1"] != "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d" {
+		t.Fatalf("2: bad sha1 %s", actual["sha1"])
+	}
+
+	expected := Checksums{
+		"sha256": "2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824",
+		"md5":    "5d41402abc4b2a76b9719d911017c592",
+		"crc32":  "ignored",
+	}
+	err := expected.Verify("hello", actual)
+	if err != nil {
+		t.Fatalf("3: %v", err)
+	}
+
+	expected["sha1"] = "0000000000000000000000000000000000000000"
+	err = expected.Verify("hello", actual)
+	var merr *ChecksumMismatchError
+	if !errors.As(err, &merr) || merr.Algorithm != "sha1" {
+		t.Fatalf("4: expected sha1 mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"github.com/integrii/flaggy"
+	"github.com/tadhunt/papertool"
+	"path/filepath"
+	"time"
+)
+
+func newBackupCmd() *Cmd {
+	dir := ""
+	to := ""
+	format := string(papertool.BackupTarZst)
+	include := []string{}
+	exclude := []string{}
+	keep := 0
+	maxAge := time.Duration(0)
+
+	cmd := flaggy.NewSubcommand("backup")
+	cmd.Description = "Archive a server directory and prune old backups"
+
+	cmd.String(&dir, "", "dir", "[required] server directory to back up")
+	cmd.String(&to, "", "to", "[optional] directory to write the backup to (defaults to backups/ in -dir)")
+	cmd.String(&format, "", "format", "[optional] archive format: tar.zst or zip")
+	cmd.StringSlice(&include, "", "include", "[optional] glob of paths to back up, relative to -dir (repeatable, defaults to everything)")
+	cmd.StringSlice(&exclude, "", "exclude", "[optional] glob of paths to leave out, relative to -dir (repeatable)")
+	cmd.Int(&keep, "", "keep", "[optional] number of backups to keep, 0 for no limit")
+	cmd.Duration(&maxAge, "", "max-age", "[optional] remove backups older than this, e.g. 720h, 0 for no limit")
+
+	handler := func(cmd *Cmd) error {
+		if dir == "" {
+			return fmt.Errorf("-dir is required")
+		}
+
+		opts := papertool.DefaultBackupOptions()
+		opts.Format = papertool.BackupFormat(format)
+		opts.DstDir = to
+		opts.Include = include
+		opts.Exclude = exclude
+
+		return backup(dir, opts, keep, maxAge)
+	}
+
+	return &Cmd{cmd: cmd, handler: handler, noProject: true}
+}
+
+// backup backs up dir, then prunes the backups next to the new one.
+func backup(dir string, opts *papertool.BackupOptions, keep int, maxAge time.Duration) error {
+	if keep < 0 {
+		return fmt.Errorf("-keep: must not be negative")
+	}
+
+	path, err := papertool.Backup(dir, opts)
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Printf("Backed up %s to %s\n", dir, path)
+	}
+
+	removed, err := papertool.PruneBackups(filepath.Dir(path), keep, maxAge, time.Now())
+	for _, r := range removed {
+		if !quiet {
+			fmt.Printf("Removed old backup %s\n", r)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/tadhunt/papertool"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+ * CLI defaults can come from a TOML config file, by default
+ * papertool/config.toml in the user's config directory (os.UserConfigDir:
+ * ~/.config on Linux, ~/Library/Application Support on macOS):
+ *
+ *   provider = "fill"
+ *   server   = "https://fill.papermc.io"
+ *   project  = "paper"
+ *
+ *   [servers]
+ *   purpur = "https://purpur.example.com"
+ *
+ *   [profiles.proxy]
+ *   project         = "velocity"
+ *   project-version = "3.4.0"
+ *   dstdir          = "/srv/velocity"
+ *   channel         = "STABLE"
+ *
+ * and from PAPERTOOL_* environment variables (PAPERTOOL_PROJECT,
+ * PAPERTOOL_PROJECT_VERSION, ...). PAPERTOOL_CONFIG and PAPERTOOL_PROFILE
+ * stand in for -config and -profile.
+ *
+ * Precedence, highest first: flags, environment, the selected profile,
+ * the top level of the config file, built in defaults.
+ *
+ * A server URL only makes sense for one provider, so a section's server
+ * is only used with the provider named in the same section (a profile
+ * that names none inherits the top level's). Servers for other providers
+ * go in a [servers] table keyed by provider name.
+ */
+
+type Settings struct {
+	Provider       string `toml:"provider"`
+	Server         string `toml:"server"`
+	Project        string `toml:"project"`
+	ProjectVersion string `toml:"project-version"`
+	DstDir         string `toml:"dstdir"`
+	Channel        string `toml:"channel"`
+
+	// Servers maps provider names to server URLs.
+	Servers map[string]string `toml:"servers"`
+}
+
+type Config struct {
+	Settings
+	Profiles map[string]*Settings `toml:"profiles"`
+
+	profile *Settings
+}
+
+// get returns the named setting from s; names match the toml keys.
+func (s *Settings) get(key string) string {
+	if s == nil {
+		return ""
+	}
+
+	switch key {
+	case "provider":
+		return s.Provider
+	case "server":
+		return s.Server
+	case "project":
+		return s.Project
+	case "project-version":
+		return s.ProjectVersion
+	case "dstdir":
+		return s.DstDir
+	case "channel":
+		return s.Channel
+	}
+
+	return ""
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "papertool", "config.toml")
+}
+
+// loadConfig reads the config file and selects the profile. A missing
+// config file is only an error if one was asked for explicitly.
+func loadConfig(path string, profile string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv("PAPERTOOL_CONFIG")
+	}
+	if profile == "" {
+		profile = os.Getenv("PAPERTOOL_PROFILE")
+	}
+
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	cfg := &Config{}
+
+	if path != "" {
+		_, err := toml.DecodeFile(path, cfg)
+		if err != nil && (explicit || !os.IsNotExist(err)) {
+			return nil, fmt.Errorf("config %s: %v", path, err)
+		}
+	}
+
+	if profile != "" {
+		p, ok := cfg.Profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("profile '%s' not found in %s", profile, path)
+		}
+		cfg.profile = p
+	}
+
+	return cfg, nil
+}
+
+// envName maps a setting name to its environment variable.
+func envName(key string) string {
+	return "PAPERTOOL_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// setting resolves a setting that wasn't given as a flag: environment,
+// then profile, then the top level of the config file, then def.
+func (c *Config) setting(key string, def string) string {
+	v := os.Getenv(envName(key))
+	if v != "" {
+		return v
+	}
+
+	v = c.profile.get(key)
+	if v != "" {
+		return v
+	}
+
+	v = c.Settings.get(key)
+	if v != "" {
+		return v
+	}
+
+	return def
+}
+
+// server resolves -server for provider when it wasn't given as a flag.
+// See the comment at the top of the file for which settings count.
+func (c *Config) server(provider string) string {
+	v := os.Getenv(envName("server"))
+	if v != "" {
+		return v
+	}
+
+	if c.profile != nil {
+		v = c.profile.Servers[provider]
+		if v != "" {
+			return v
+		}
+
+		sectionProvider := c.profile.Provider
+		if sectionProvider == "" {
+			sectionProvider = c.Settings.Provider
+		}
+		if c.profile.Server != "" && sectionProvider == provider {
+			return c.profile.Server
+		}
+	}
+
+	v = c.Settings.Servers[provider]
+	if v != "" {
+		return v
+	}
+
+	if c.Settings.Server != "" && c.Settings.Provider == provider {
+		return c.Settings.Server
+	}
+
+	return papertool.ProviderDefaultServer(provider)
+}
+
+// apply fills in *flag from the config if the flag wasn't given.
+func (c *Config) apply(flag *string, key string, def string) {
+	if *flag == "" {
+		*flag = c.setting(key, def)
+	}
+}
@@ -3,17 +3,20 @@ module main
 go 1.24.2
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/integrii/flaggy v1.5.2
 	github.com/tadhunt/papertool v0.0.0-00010101000000-000000000000
 )
 
 require (
+	github.com/klauspost/compress v1.19.2 // indirect
 	github.com/tadhunt/go-dl-stream/v2 v2.0.3 // indirect
 	github.com/tadhunt/logger v0.0.0-20250303180812-6aad7c71b986 // indirect
 	golang.org/x/mod v0.23.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
 	golang.org/x/text v0.11.0 // indirect
 	golang.org/x/tools v0.30.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/tadhunt/papertool => ./..
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/integrii/flaggy"
+	"github.com/tadhunt/papertool"
+	"os"
+)
+
+func newHistoryCmd() *Cmd {
+	dir := "."
+	noChanges := false
+	rawJson := false
+
+	cmd := flaggy.NewSubcommand("history")
+	cmd.Description = "Show what has been installed in a directory, with the changes between installs"
+
+	cmd.String(&dir, "", "dir", "[optional] directory to show the history of")
+	cmd.Bool(&noChanges, "", "no-changes", "[optional] don't fetch the changes between installs")
+	cmd.Bool(&rawJson, "", "json", "[optional] dump the history as json")
+
+	handler := func(cmd *Cmd) error {
+		entries, err := papertool.ReadHistory(dir)
+		if err != nil {
+			return err
+		}
+
+		if rawJson {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+
+		if len(entries) == 0 {
+			return fmt.Errorf("%s: no history", dir)
+		}
+
+		for i, e := range entries {
+			if i > 0 {
+				fmt.Printf("----------\n")
+			}
+
+			fmt.Printf("Time     %s\n", formatTime(e.Time, e.Time.Format("2006-01-02T15:04:05Z07:00")))
+			fmt.Printf("Build    %s %s build %s\n", e.Project, e.Version, e.Build)
+			fmt.Printf("Artifact %s sha256 %s\n", e.Artifact, e.Sha256)
+			fmt.Printf("Source   %s\n", e.URL)
+
+			if noChanges || i == 0 {
+				continue
+			}
+
+			prev := entries[i-1]
+			if prev.Project != e.Project || prev.Version != e.Version {
+				fmt.Printf("Replaces %s %s build %s\n", prev.Project, prev.Version, prev.Build)
+				continue
+			}
+
+			p, err := historyProvider(e.Project)
+			if err != nil {
+				return err
+			}
+
+			builds, err := papertool.ChangesBetween(p, prev, e)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: changes since build %s: %v\n", prev.Build, err)
+				continue
+			}
+
+			for _, b := range builds {
+				for _, change := range b.Changes {
+					fmt.Printf("Change   build %s %s\n", papertool.String(b.Build), papertool.String(change.Commit))
+					fmt.Printf("%s", cleanComment(papertool.String(change.Message)))
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler, noProject: true}
+}
+
+// historyProvider returns the provider to look up project's changes with:
+// the one selected by the global flags if it serves project, otherwise
+// the default provider for project on its default server.
+func historyProvider(project string) (papertool.Provider, error) {
+	return papertool.ProviderServing(provider, project)
+}
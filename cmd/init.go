@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"github.com/integrii/flaggy"
+	"github.com/tadhunt/papertool"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func newInitCmd() *Cmd {
+	dir := ""
+	build := ""
+	memory := "4G"
+	java := ""
+	javaHome := ""
+	acceptEULA := false
+	replace := false
+
+	cmd := flaggy.NewSubcommand("init")
+	cmd.Description = "Set up a server directory: download the build, write a start script and record what was installed"
+
+	cmd.String(&dir, "", "dir", "[required] server directory to set up (created if missing)")
+	cmd.String(&build, "", "build", "[optional] build to install (defaults to latest)")
+	cmd.String(&memory, "", "memory", "[optional] heap size for the start script, e.g. 512M or 4G")
+	cmd.String(&java, "", "java", "[optional] java binary for the start script to run (defaults to java on $PATH)")
+	cmd.String(&javaHome, "", "java-home", "[optional] Java installation to check the build against (defaults to java on $PATH)")
+	cmd.Bool(&acceptEULA, "", "accept-eula", "[optional] accept the Minecraft EULA (https://aka.ms/MinecraftEULA) by writing eula.txt")
+	cmd.Bool(&replace, "", "replace", "[optional] replace an existing jar and start script")
+
+	handler := func(cmd *Cmd) error {
+		if dir == "" {
+			return fmt.Errorf("-dir is required")
+		}
+
+		err := papertool.CheckMemory(memory)
+		if err != nil {
+			return fmt.Errorf("-memory: %v", err)
+		}
+
+		b, err := resolveBuild(build)
+		if err != nil {
+			return err
+		}
+
+		err = checkVersion(javaHome, "warn")
+		if err != nil {
+			return err
+		}
+
+		artifact, err := papertool.ResolveArtifact(provider, paperProject, paperProjectVersion, b)
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stat(filepath.Join(dir, papertool.StartScriptName))
+		if err == nil && !replace {
+			return fmt.Errorf("%s: already has a %s, use -replace to overwrite it", dir, papertool.StartScriptName)
+		}
+
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return err
+		}
+
+		reporter, err := newProgressReporter(os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		opts := papertool.DefaultDownloadOptions()
+		opts.Replace = replace
+		opts.Progress = reporter
+		opts.History = true
+
+		err = papertool.DownloadOpts(serverURL, paperProject, paperProjectVersion, papertool.String(b.Build), artifact, dir, opts)
+		if err != nil {
+			return err
+		}
+
+		if acceptEULA {
+			err = papertool.AcceptEULA(dir, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+
+		script := &papertool.StartScript{
+			Java:     java,
+			Memory:   memory,
+			JVMFlags: recommendedJVMFlags(),
+			Jar:      papertool.String(artifact.Application.Name),
+		}
+
+		err = script.Write(dir)
+		if err != nil {
+			return err
+		}
+
+		rec := &papertool.InstallRecord{
+			Provider:    provider.Name(),
+			Server:      serverURL.String(),
+			Project:     paperProject,
+			Version:     paperProjectVersion,
+			Build:       papertool.String(b.Build),
+			Channel:     channel,
+			Jar:         script.Jar,
+			Checksums:   artifact.Application.ExpectedChecksums().Known(),
+			Java:        java,
+			Memory:      memory,
+			JVMFlags:    script.JVMFlags,
+			InstalledAt: time.Now().UTC(),
+		}
+
+		err = papertool.WriteInstallRecord(dir, rec)
+		if err != nil {
+			return err
+		}
+
+		if !quiet {
+			fmt.Printf("Installed %s %s build %s in %s\n", rec.Project, rec.Version, rec.Build, dir)
+			if !acceptEULA {
+				fmt.Printf("The server won't start until the EULA is accepted in %s (or rerun with -accept-eula)\n", filepath.Join(dir, papertool.EULAName))
+			}
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler}
+}
+
+// resolveBuild finds build ("" or "latest" for the newest good build) of
+// -project-version in -channel, filling in -project-version with the
+// newest version if it wasn't given.
+func resolveBuild(build string) (*papertool.Build, error) {
+	if paperProjectVersion == "" {
+		versions, err := provider.ListVersions(paperProject)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions.Versions) == 0 {
+			return nil, fmt.Errorf("no versions")
+		}
+		paperProjectVersion = versions.Versions[len(versions.Versions)-1]
+	}
+
+	builds, err := provider.ListBuilds(paperProject, paperProjectVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	builds = builds.InChannel(channel)
+
+	if len(builds.Builds) == 0 {
+		return nil, fmt.Errorf("no builds")
+	}
+
+	i, err := findBuildIndex(builds, build)
+	if err != nil {
+		return nil, err
+	}
+
+	return builds.Builds[i], nil
+}
+
+// recommendedJVMFlags returns the flags the provider recommends for
+// -project-version, or papertool.DefaultJVMFlags if it doesn't say.
+func recommendedJVMFlags() []string {
+	detailer, ok := provider.(papertool.VersionDetailer)
+	if ok {
+		v, err := detailer.GetVersion(paperProject, paperProjectVersion)
+		if err == nil && len(v.JavaFlags) > 0 {
+			return v.JavaFlags
+		}
+	}
+
+	return papertool.DefaultJVMFlags
+}
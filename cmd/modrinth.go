@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"github.com/integrii/flaggy"
+	"github.com/tadhunt/papertool"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// modrinth is a command group under plugins; its handler runs whichever
+// subcommand was used.
+type modrinthCmd struct {
+	plugins  *pluginsCmd
+	server   string
+	loader   string
+	subcmds  []*Cmd
+	modrinth *papertool.Modrinth
+}
+
+func (p *pluginsCmd) newModrinthCmd() *Cmd {
+	m := &modrinthCmd{
+		plugins: p,
+		server:  papertool.ModrinthServer,
+	}
+
+	cmd := flaggy.NewSubcommand("modrinth")
+	cmd.Description = "List and download plugins from Modrinth"
+
+	cmd.String(&m.server, "", "modrinth-api", "[optional] URL of the Modrinth API (defaults to https://api.modrinth.com)")
+	cmd.String(&m.loader, "", "loader", "[optional] loader to filter by, e.g. paper or velocity (defaults to the one matching -platform)")
+
+	m.subcmds = []*Cmd{
+		m.newVersionsCmd(),
+		m.newDownloadCmd(),
+	}
+	for _, sub := range m.subcmds {
+		cmd.AttachSubcommand(sub.cmd, 1)
+	}
+
+	handler := func(cmd *Cmd) error {
+		sub := usedCmd(m.subcmds)
+		if sub == nil {
+			return fmt.Errorf("modrinth: one of %s is required", subcommandNames(m.subcmds))
+		}
+
+		u, err := url.Parse(m.server)
+		if err != nil {
+			return fmt.Errorf("-modrinth-api: %v", err)
+		}
+		m.modrinth = papertool.NewModrinth(u)
+
+		if m.loader == "" {
+			m.loader = papertool.ModrinthLoader(p.platform)
+		}
+
+		return sub.handler(sub)
+	}
+
+	return &Cmd{cmd: cmd, handler: handler}
+}
+
+func (m *modrinthCmd) newVersionsCmd() *Cmd {
+	slug := ""
+	showChanges := false
+
+	cmd := flaggy.NewSubcommand("versions")
+	cmd.Description = "List a plugin's versions, newest first"
+
+	cmd.AddPositionalValue(&slug, "plugin", 1, true, "the plugin's Modrinth slug")
+	cmd.Bool(&showChanges, "", "changes", "[optional] show each version's changelog")
+
+	handler := func(cmd *Cmd) error {
+		versions, err := m.modrinth.ListVersions(slug, m.loader, paperProjectVersion)
+		if err != nil {
+			return err
+		}
+
+		if len(versions) == 0 {
+			return fmt.Errorf("%s: no versions for %s %s", slug, m.loader, paperProjectVersion)
+		}
+
+		for i, v := range versions {
+			if i > 0 {
+				fmt.Printf("----------\n")
+			}
+			fmt.Printf("Version   %s\n", v.VersionNumber)
+			fmt.Printf("Name      %s\n", v.Name)
+			fmt.Printf("Type      %s\n", v.VersionType)
+			fmt.Printf("Published %s\n", formatTime(v.Published, v.Published.Format("2006-01-02T15:04:05Z07:00")))
+			fmt.Printf("Loaders   %s\n", strings.Join(v.Loaders, ", "))
+			fmt.Printf("Supports  %s\n", strings.Join(v.GameVersions, ", "))
+			if f, err := v.PrimaryFile(); err == nil {
+				fmt.Printf("File      %s sha512 %s\n", f.FileName, f.Hashes["sha512"])
+			}
+			if showChanges && v.Changelog != "" {
+				fmt.Printf("%s\n", v.Changelog)
+			}
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler}
+}
+
+func (m *modrinthCmd) newDownloadCmd() *Cmd {
+	slug := ""
+	version := ""
+	dir := "plugins"
+	replace := false
+
+	cmd := flaggy.NewSubcommand("download")
+	cmd.Description = "Download a plugin's primary file into a server's plugins directory"
+
+	cmd.AddPositionalValue(&slug, "plugin", 1, true, "the plugin's Modrinth slug")
+	cmd.String(&version, "", "plugin-version", "[optional] plugin version number to download (defaults to the newest for -loader and -project-version)")
+	cmd.String(&dir, "", "dir", "[optional] directory to download into")
+	cmd.Bool(&replace, "", "replace", "[optional] replace the jar if it already exists, and remove other jars carrying the same plugin (e.g. the previous version)")
+
+	handler := func(cmd *Cmd) error {
+		versions, err := m.modrinth.ListVersions(slug, m.loader, paperProjectVersion)
+		if err != nil {
+			return err
+		}
+
+		var v *papertool.ModrinthVersion
+		for _, candidate := range versions {
+			if version == "" || candidate.VersionNumber == version {
+				v = candidate
+				break
+			}
+		}
+		if v == nil {
+			if version != "" {
+				return fmt.Errorf("-plugin-version: %s has no version '%s' for %s %s", slug, version, m.loader, paperProjectVersion)
+			}
+			return fmt.Errorf("%s: no versions for %s %s", slug, m.loader, paperProjectVersion)
+		}
+
+		st, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("%s: %v", dir, err)
+		}
+		if !st.IsDir() {
+			return fmt.Errorf("%s: is not a directory", dir)
+		}
+
+		reporter, err := newProgressReporter(os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		opts := papertool.DefaultDownloadOptions()
+		opts.Replace = replace
+		opts.Progress = reporter
+
+		artifact, err := v.Artifact()
+		if err != nil {
+			return err
+		}
+
+		replaced, err := papertool.InstallPlugin(artifact, dir, opts)
+		if err != nil {
+			return err
+		}
+		for _, path := range replaced {
+			fmt.Printf("Removed %s\n", path)
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler}
+}
@@ -1,24 +1,36 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/integrii/flaggy"
 	"github.com/tadhunt/papertool"
 	"strings"
 	"net/url"
 	"os"
+	"path/filepath"
+	"time"
 )
 
 type Cmd struct {
-	cmd     *flaggy.Subcommand
-	handler func(cmd *Cmd) error
+	cmd       *flaggy.Subcommand
+	handler   func(cmd *Cmd) error
+	noProject bool // -project isn't needed by this command
 }
 
 var (
 	serverURL *url.URL
+	provider  papertool.Provider
 	quiet     = false
 	paperProject   = ""
 	paperProjectVersion = ""
+	progressMode = "auto"
+	pinMode = "warn"
+	pinDB = ""
+	timeFormat = "raw"
+	channel = ""
+	config *Config
 
 )
 
@@ -28,16 +40,34 @@ func main() {
 	flaggy.DefaultParser.AdditionalHelpPrepend = "https://github.com/tadhunt/papertool"
 	flaggy.SetVersion(MainSemanticVersion)
 
-	server := "https://fill.papermc.io"
-	flaggy.String(&server, "", "server", "[required] URL of papermc.io server to interact with")
+	server := ""
+	providerName := ""
+	configPath := ""
+	profile := ""
+	flaggy.String(&configPath, "", "config", fmt.Sprintf("[optional] config file to read defaults from (defaults to %s)", defaultConfigPath()))
+	flaggy.String(&profile, "", "profile", "[optional] named profile from the config file to take defaults from")
+	flaggy.String(&providerName, "", "provider", fmt.Sprintf("[optional] upstream to fetch builds from: %s (defaults to the one named after -project if there is one, otherwise %s)", strings.Join(papertool.ProviderNames(), ", "), papertool.DefaultProvider))
+	flaggy.String(&server, "", "server", "[optional] URL of the provider's server to interact with (defaults to the provider's own, e.g. https://fill.papermc.io)")
 	flaggy.Bool(&quiet, "", "quiet", "[optional] don't print extra info")
 	flaggy.String(&paperProject, "", "project", "[required] Paper project to fetch data from")
 	flaggy.String(&paperProjectVersion, "", "project-version", "[optional] version of the project to fetch data from")
+	flaggy.String(&channel, "", "channel", "[optional] only consider builds in this channel (e.g. STABLE)")
+	flaggy.String(&progressMode, "", "progress", "[optional] download progress style: auto, tty, log, json, or none")
+	flaggy.String(&timeFormat, "", "time", "[optional] how to show times: raw (as sent by the server), utc, local, or relative")
+	flaggy.String(&pinMode, "", "pin", "[optional] what to do when a build's checksum differs from the one first recorded for it: warn, strict, or off")
+	flaggy.String(&pinDB, "", "pin-db", "[optional] known checksums database (defaults to known-checksums.json in the user config dir)")
 
 	cmds := []*Cmd{
 		newGetCmd(),
 		newDownloadCmd(),
 		newVersionsCmd(),
+		newVerifyCmd(),
+		newProjectsCmd(),
+		newPluginsCmd(),
+		newInitCmd(),
+		newRunCmd(),
+		newBackupCmd(),
+		newHistoryCmd(),
 	}
 
 	for _, cmd := range cmds {
@@ -46,34 +76,69 @@ func main() {
 
 	flaggy.Parse()
 
+	var err error
+	config, err = loadConfig(configPath, profile)
+	if err != nil {
+		flaggy.DefaultParser.ShowHelpWithMessage(err.Error())
+		return
+	}
+
+	config.apply(&paperProject, "project", "")
+	config.apply(&providerName, "provider", papertool.ProviderForProject(paperProject))
+	if server == "" {
+		server = config.server(providerName)
+	}
+	config.apply(&paperProjectVersion, "project-version", "")
+	config.apply(&channel, "channel", "")
+
 	if server == "" {
 		flaggy.DefaultParser.ShowHelpWithMessage("-server is required")
 		return
 	}
 
-	if paperProject == "" {
+	if paperProject == "" && needsProject(cmds) {
 		flaggy.DefaultParser.ShowHelpWithMessage("-project is required")
+		return
+	}
+
+	switch timeFormat {
+	case "raw", "utc", "local", "relative":
+	default:
+		flaggy.DefaultParser.ShowHelpWithMessage(fmt.Sprintf("-time: unknown format '%s'", timeFormat))
+		return
 	}
 
-	var err error
 	serverURL, err = url.Parse(server)
 	if err != nil {
 		flaggy.DefaultParser.ShowHelpWithMessage(fmt.Sprintf("parse url: %v", err))
 		return
 	}
 
+	provider, err = papertool.NewProvider(providerName, serverURL)
+	if err != nil {
+		flaggy.DefaultParser.ShowHelpWithMessage(fmt.Sprintf("-provider: %v", err))
+		return
+	}
+
+	err = openChecksumDB()
+	if err != nil {
+		flaggy.DefaultParser.ShowHelpWithMessage(fmt.Sprintf("-pin: %v", err))
+		return
+	}
+
 	for _, cmd := range cmds {
 		if cmd.cmd.Used {
 			err := cmd.handler(cmd)
 			if err != nil {
-				serr, isSyntaxError := err.(*papertool.MetadataSyntaxError)
-				if isSyntaxError {
-					os.Stderr.WriteString(serr.Raw)
-					fmt.Fprintf(os.Stderr, "offset %v\n", serr.Offset)
-					flaggy.DefaultParser.ShowHelpWithMessage(fmt.Sprintf("cmd %s: %v", cmd.cmd.Name, err))
-				} else {
-					flaggy.DefaultParser.ShowHelpWithMessage(fmt.Sprintf("cmd %s: %v", cmd.cmd.Name, err))
+				// Only pay for the project listing when something wasn't
+				// found; a misspelled -project is the usual cause.
+				if errors.Is(err, papertool.ErrNotFound) && paperProject != "" && needsProject(cmds) {
+					perr := checkProject()
+					if perr != nil {
+						err = fmt.Errorf("-project: %v", perr)
+					}
 				}
+				os.Exit(reportError(cmd, err))
 			}
 			return
 
@@ -81,6 +146,121 @@ func main() {
 	}
 }
 
+// Exit codes, so scripts can tell API failures apart. 2 is flaggy's usage
+// error.
+const (
+	exitError       = 1
+	exitNotFound    = 3
+	exitRateLimited = 4
+	exitServer      = 5
+	exitDecode      = 6
+)
+
+// reportError prints err for the user and returns the exit code to use.
+// API errors get a short explanation; anything else is assumed to be a
+// usage problem and gets the help text too.
+func reportError(cmd *Cmd, err error) int {
+	var (
+		be   *papertool.BatchError
+		nf   *papertool.NotFoundError
+		rl   *papertool.RateLimitedError
+		se   *papertool.ServerError
+		serr *papertool.MetadataSyntaxError
+	)
+
+	switch {
+	case errors.As(err, &be):
+		// The individual failures may be of any kind; just list them.
+		fmt.Fprintf(os.Stderr, "cmd %s: %v\n", cmd.cmd.Name, be)
+		return exitError
+
+	case errors.As(err, &nf):
+		fmt.Fprintf(os.Stderr, "cmd %s: not found: %s\n", cmd.cmd.Name, nf.Message)
+		fmt.Fprintf(os.Stderr, "check -project, -project-version and -build (%s)\n", nf.URL)
+		return exitNotFound
+
+	case errors.As(err, &rl):
+		if rl.RetryAfter > 0 {
+			fmt.Fprintf(os.Stderr, "cmd %s: the server is rate limiting requests, try again in %v\n", cmd.cmd.Name, rl.RetryAfter)
+		} else {
+			fmt.Fprintf(os.Stderr, "cmd %s: the server is rate limiting requests, try again later\n", cmd.cmd.Name)
+		}
+		return exitRateLimited
+
+	case errors.As(err, &se):
+		if errors.Is(err, papertool.ErrServer) {
+			fmt.Fprintf(os.Stderr, "cmd %s: the server had a problem (status %d), try again later: %s\n", cmd.cmd.Name, se.StatusCode, se.Message)
+			return exitServer
+		}
+		fmt.Fprintf(os.Stderr, "cmd %s: the server rejected the request (status %d): %s\n", cmd.cmd.Name, se.StatusCode, se.Message)
+		return exitError
+
+	case errors.As(err, &serr):
+		os.Stderr.WriteString(serr.Raw)
+		fmt.Fprintf(os.Stderr, "offset %v\n", serr.Offset)
+		fmt.Fprintf(os.Stderr, "cmd %s: the server sent malformed metadata: %v\n", cmd.cmd.Name, err)
+		return exitDecode
+
+	case errors.Is(err, papertool.ErrDecode):
+		fmt.Fprintf(os.Stderr, "cmd %s: the server sent metadata papertool doesn't understand: %v\n", cmd.cmd.Name, err)
+		return exitDecode
+	}
+
+	flaggy.DefaultParser.ShowHelpWithMessage(fmt.Sprintf("cmd %s: %v", cmd.cmd.Name, err))
+
+	return exitError
+}
+
+func openChecksumDB() error {
+	var mode papertool.PinMode
+	switch pinMode {
+	case "off":
+		return nil
+	case "warn", "":
+		mode = papertool.PinWarn
+	case "strict":
+		mode = papertool.PinStrict
+	default:
+		return fmt.Errorf("unknown mode '%s'", pinMode)
+	}
+
+	path := pinDB
+	if path == "" {
+		var err error
+		path, err = papertool.DefaultChecksumDBPath()
+		if err != nil {
+			return err
+		}
+	}
+
+	db, err := papertool.OpenChecksumDB(path, mode)
+	if err != nil {
+		return err
+	}
+	papertool.KnownChecksums = db
+
+	return nil
+}
+
+func usedCmd(cmds []*Cmd) *Cmd {
+	for _, cmd := range cmds {
+		if cmd.cmd.Used {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+func needsProject(cmds []*Cmd) bool {
+	cmd := usedCmd(cmds)
+	if cmd == nil {
+		return true
+	}
+
+	return !cmd.noProject
+}
+
 func newGetCmd() *Cmd {
 	build := ""
 	since := ""
@@ -97,7 +277,7 @@ func newGetCmd() *Cmd {
 
 	handler := func(cmd *Cmd) error {
 		if paperProjectVersion == "" {
-			versions, err := papertool.GetVersions(serverURL, paperProject)
+			versions, err := provider.ListVersions(paperProject)
 			if err != nil {
 				return err
 			}
@@ -107,7 +287,7 @@ func newGetCmd() *Cmd {
 			paperProjectVersion = versions.Versions[len(versions.Versions)-1]
 		}
 
-		builds, err := papertool.GetBuilds(serverURL, paperProject, paperProjectVersion)
+		builds, err := provider.ListBuilds(paperProject, paperProjectVersion)
 		if err != nil {
 			return err
 		}
@@ -117,13 +297,15 @@ func newGetCmd() *Cmd {
 			return nil
 		}
 
+		builds = builds.InChannel(channel)
+
 		if len(builds.Builds) == 0 {
 			return fmt.Errorf("no builds")
 		}
 
-		currentBuildIndex := builds.FindBuildIndex(build)
-		if currentBuildIndex < 0 {
-			return fmt.Errorf("-build: build '%s' not found", build)
+		currentBuildIndex, err := findBuildIndex(builds, build)
+		if err != nil {
+			return err
 		}
 
 		finalBuildIndex := builds.FindBuildIndex(since)
@@ -138,18 +320,34 @@ func newGetCmd() *Cmd {
 			}
 
 			currentBuild := builds.Builds[currentBuildIndex]
+			if currentBuild.Artifact == nil {
+				// Not every provider's listing includes the artifact.
+				full, err := provider.GetBuild(paperProject, paperProjectVersion, papertool.String(currentBuild.Build))
+				if err != nil {
+					return err
+				}
+				currentBuild = full
+			}
 
 			fmt.Printf("Build    %s\n", papertool.String(currentBuild.Build))
-			fmt.Printf("Time     %s\n", papertool.String(currentBuild.Time))
-			fmt.Printf("Channel  %s\n", papertool.String(currentBuild.Channel))
+			if currentBuild.Time != nil {
+				fmt.Printf("Time     %s\n", formatTime(currentBuild.Timestamp, *currentBuild.Time))
+			}
+			if currentBuild.Channel != nil {
+				fmt.Printf("Channel  %s\n", *currentBuild.Channel)
+			}
 
 			if currentBuild.Artifact != nil && currentBuild.Artifact.Application != nil {
-				fmt.Printf("Artifact %s sha256 %s\n", papertool.String(currentBuild.Artifact.Application.Name), papertool.String(currentBuild.Artifact.Application.Sha256))
+				fmt.Printf("Artifact %s %s\n", papertool.String(currentBuild.Artifact.Application.Name), artifactChecksum(currentBuild.Artifact.Application))
 			}
 
 			if showChanges {
 				for _, change := range currentBuild.Changes {
-					fmt.Printf("Change %s\n", papertool.String(change.Commit))
+					if change.Timestamp.IsZero() {
+						fmt.Printf("Change %s\n", papertool.String(change.Commit))
+					} else {
+						fmt.Printf("Change %s %s\n", papertool.String(change.Commit), formatTime(change.Timestamp, papertool.String(change.Time)))
+					}
 					comment := cleanComment(papertool.String(change.Message))
 					os.Stdout.WriteString(comment)
 				}
@@ -177,6 +375,53 @@ func newGetCmd() *Cmd {
 	return &Cmd{cmd: get, handler: handler}
 }
 
+// findBuildIndex returns the index of build in builds. "" and "latest"
+// mean the newest build that actually produced a jar, so a failed build at
+// the end of a Purpur listing is passed over.
+func findBuildIndex(builds *papertool.Builds, build string) (int, error) {
+	if build != "" && build != "latest" {
+		i := builds.FindBuildIndex(build)
+		if i < 0 {
+			return -1, fmt.Errorf("-build: build '%s' not found", build)
+		}
+		return i, nil
+	}
+
+	latest, err := papertool.LatestBuild(provider, paperProject, paperProjectVersion, builds)
+	if err != nil {
+		return -1, err
+	}
+
+	i := builds.FindBuildIndex(papertool.String(latest.Build))
+	if i < 0 {
+		return -1, fmt.Errorf("-build: build '%s' not found", papertool.String(latest.Build))
+	}
+
+	// Keep the fetched build so it isn't fetched again.
+	builds.Builds[i] = latest
+
+	return i, nil
+}
+
+// formatTime renders t according to -time. raw is the time as the server
+// sent it.
+func formatTime(t time.Time, raw string) string {
+	if t.IsZero() {
+		return raw
+	}
+
+	switch timeFormat {
+	case "utc":
+		return t.UTC().Format(time.RFC3339)
+	case "local":
+		return t.Local().Format("2006-01-02 15:04:05 MST")
+	case "relative":
+		return papertool.RelativeTime(t, time.Now())
+	}
+
+	return raw
+}
+
 func cleanComment(comment string) string {
 	comment = strings.TrimRight(comment, "\n")
 	comment = strings.ReplaceAll(comment, "\n", "\n\t")
@@ -186,19 +431,37 @@ func cleanComment(comment string) string {
 
 func newDownloadCmd() *Cmd {
 	build := ""
+	since := ""
 	dstdir := ""
 	replace := false
+	jobs := 4
+	limitRate := ""
+	output := ""
+	javaHome := ""
+	javaCheck := "warn"
+	backupFirst := false
+	backupFormat := string(papertool.BackupTarZst)
+	backupKeep := 0
 
 	get := flaggy.NewSubcommand("download")
 	get.Description = "download build artifact"
 
 	get.String(&build, "", "build", "[optional] Build to fetch (defaults to latest)")
+	get.String(&since, "", "since", "[optional] Download all builds between -build and this one")
 	get.String(&dstdir, "", "dstdir", "[optional] Destination directory to download artifact(s) into")
+	get.String(&output, "o", "output", "[optional] file to write the artifact to instead of its name in -dstdir, or - for stdout")
 	get.Bool(&replace, "", "replace", "[optional] replace artifacts if they already exist")
+	get.Bool(&backupFirst, "", "backup", "[optional] with -replace, back up -dstdir into -dstdir/backups first")
+	get.String(&backupFormat, "", "backup-format", "[optional] archive format for -backup: tar.zst or zip")
+	get.Int(&backupKeep, "", "backup-keep", "[optional] number of -backup archives to keep, 0 for no limit")
+	get.Int(&jobs, "", "jobs", "[optional] number of concurrent downloads when used with -since")
+	get.String(&javaHome, "", "java-home", "[optional] Java installation to check the build against (defaults to java on $PATH)")
+	get.String(&javaCheck, "", "java-check", "[optional] what to do if the local Java is too old for the build: warn, fail, or off")
+	get.String(&limitRate, "", "limit-rate", "[optional] cap total download bandwidth, in bytes/sec with optional K, M or G suffix (e.g. 2M)")
 
 	handler := func(cmd *Cmd) error {
 		if paperProjectVersion == "" {
-			versions, err := papertool.GetVersions(serverURL, paperProject)
+			versions, err := provider.ListVersions(paperProject)
 			if err != nil {
 				return err
 			}
@@ -208,24 +471,72 @@ func newDownloadCmd() *Cmd {
 			paperProjectVersion = versions.Versions[len(versions.Versions)-1]
 		}
 
-		builds, err := papertool.GetBuilds(serverURL, paperProject, paperProjectVersion)
+		builds, err := provider.ListBuilds(paperProject, paperProjectVersion)
 		if err != nil {
 			return err
 		}
 
+		builds = builds.InChannel(channel)
+
 		if len(builds.Builds) == 0 {
 			return fmt.Errorf("no builds")
 		}
 
-		buildIndex := builds.FindBuildIndex(build)
-		if buildIndex < 0 {
-			return fmt.Errorf("-build: build '%s' not found", build)
+		buildIndex, err := findBuildIndex(builds, build)
+		if err != nil {
+			return err
 		}
 
-		if dstdir == "" {
-			dstdir = "."
+		switch javaCheck {
+		case "warn", "fail", "off":
+		default:
+			return fmt.Errorf("-java-check: unknown mode '%s'", javaCheck)
 		}
 
+		if backupFirst && !replace {
+			return fmt.Errorf("-backup only applies with -replace")
+		}
+
+		err = checkVersion(javaHome, javaCheck)
+		if err != nil {
+			return err
+		}
+
+		var limiter *papertool.RateLimiter
+		if limitRate != "" {
+			rate, err := papertool.ParseRate(limitRate)
+			if err != nil {
+				return fmt.Errorf("-limit-rate: %v", err)
+			}
+			limiter = papertool.NewRateLimiter(rate)
+		}
+
+		if output != "" && since != "" {
+			return fmt.Errorf("-output can't be used with -since")
+		}
+
+		if output == "-" {
+			b := builds.Builds[buildIndex]
+			artifact, err := papertool.ResolveArtifact(provider, paperProject, paperProjectVersion, b)
+			if err != nil {
+				return err
+			}
+
+			// stdout is carrying the jar, so progress goes to stderr.
+			reporter, err := newProgressReporter(os.Stderr)
+			if err != nil {
+				return err
+			}
+
+			opts := papertool.DefaultDownloadOptions()
+			opts.Progress = reporter
+			opts.RateLimit = limiter
+
+			return papertool.DownloadToOpts(context.Background(), paperProject, paperProjectVersion, papertool.String(b.Build), artifact, os.Stdout, opts)
+		}
+
+		config.apply(&dstdir, "dstdir", ".")
+
 		st, err := os.Stat(dstdir)
 		if os.Stat(dstdir); err != nil {
 			return fmt.Errorf("%s: %v", dstdir, err)
@@ -234,9 +545,117 @@ func newDownloadCmd() *Cmd {
 			return fmt.Errorf("%s: is not a directory", dstdir)
 		}
 
+		// -backup is there to undo a bad update, so it's skipped when
+		// every jar is already current and nothing will be replaced.
+		backupBefore := func(artifacts ...*papertool.Artifact) error {
+			if !backupFirst {
+				return nil
+			}
+
+			stale := false
+			for _, artifact := range artifacts {
+				dst := output
+				if dst == "" {
+					dst = filepath.Join(dstdir, filepath.Base(papertool.String(artifact.Application.Name)))
+				}
+
+				current, err := papertool.ArtifactCurrent(dst, artifact)
+				if err != nil {
+					return fmt.Errorf("-backup: %v", err)
+				}
+				if !current {
+					stale = true
+					break
+				}
+			}
+			if !stale {
+				return nil
+			}
+
+			opts := papertool.DefaultBackupOptions()
+			opts.Format = papertool.BackupFormat(backupFormat)
+
+			err := backup(dstdir, opts, backupKeep, 0)
+			if err != nil {
+				return fmt.Errorf("-backup: %v", err)
+			}
+
+			return nil
+		}
+
+		if since != "" {
+			sinceIndex := builds.FindBuildIndex(since)
+			if sinceIndex < 0 {
+				return fmt.Errorf("-since: build '%s' not found", since)
+			}
+			if sinceIndex > buildIndex {
+				sinceIndex, buildIndex = buildIndex, sinceIndex
+			}
+
+			targets := []*papertool.DownloadTarget{}
+			artifacts := []*papertool.Artifact{}
+			for i := sinceIndex; i <= buildIndex; i++ {
+				b := builds.Builds[i]
+				artifact, err := papertool.ResolveArtifact(provider, paperProject, paperProjectVersion, b)
+				if err != nil {
+					return err
+				}
+				artifacts = append(artifacts, artifact)
+				targets = append(targets, &papertool.DownloadTarget{
+					Project:  paperProject,
+					Version:  paperProjectVersion,
+					Build:    papertool.String(b.Build),
+					Artifact: artifact,
+				})
+			}
+
+			err = backupBefore(artifacts...)
+			if err != nil {
+				return err
+			}
+
+			reporter, err := newProgressReporter(os.Stdout)
+			if err != nil {
+				return err
+			}
+
+			opts := &papertool.BatchOptions{
+				DstDir:  dstdir,
+				Workers: jobs,
+			}
+			opts.Replace = replace
+			opts.Progress = reporter
+			opts.RateLimit = limiter
+			opts.History = true
+
+			return papertool.DownloadBatch(serverURL, targets, opts)
+		}
+
 		b := builds.Builds[buildIndex]
 
-		err = papertool.Download(serverURL, paperProject, paperProjectVersion, build, b.Artifact, dstdir, replace, quiet)
+		artifact, err := papertool.ResolveArtifact(provider, paperProject, paperProjectVersion, b)
+		if err != nil {
+			return err
+		}
+
+		err = backupBefore(artifact)
+		if err != nil {
+			return err
+		}
+
+		reporter, err := newProgressReporter(os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		opts := papertool.DefaultDownloadOptions()
+		opts.Replace = replace
+		opts.Output = output
+		opts.Progress = reporter
+		opts.RateLimit = limiter
+		opts.History = true
+
+		err = papertool.DownloadOpts(serverURL, paperProject, paperProjectVersion, papertool.String(b.Build), artifact, dstdir, opts)
 		if err != nil {
 			return err
 		}
@@ -247,16 +666,138 @@ func newDownloadCmd() *Cmd {
 	return &Cmd{cmd: get, handler: handler}
 }
 
+func showVersionDetail(rawJson bool) error {
+	detailer, ok := provider.(papertool.VersionDetailer)
+	if !ok {
+		return fmt.Errorf("-detail: provider '%s' doesn't publish version details", provider.Name())
+	}
+
+	ids := []string{paperProjectVersion}
+	if paperProjectVersion == "" {
+		versions, err := provider.ListVersions(paperProject)
+		if err != nil {
+			return err
+		}
+		ids = versions.Versions
+	}
+
+	for i, id := range ids {
+		v, err := detailer.GetVersion(paperProject, id)
+		if err != nil {
+			return err
+		}
+
+		if rawJson {
+			os.Stdout.Write(v.Raw())
+			fmt.Printf("\n")
+			continue
+		}
+
+		if i > 0 {
+			fmt.Printf("----------\n")
+		}
+
+		fmt.Printf("Version       %s\n", v.ID)
+		if v.SupportEnd.IsZero() {
+			fmt.Printf("Support       %s\n", v.SupportStatus)
+		} else {
+			fmt.Printf("Support       %s (ends %s)\n", v.SupportStatus, v.SupportEnd.Format("2006-01-02"))
+		}
+		if v.JavaMinimum > 0 {
+			fmt.Printf("Java          %d+\n", v.JavaMinimum)
+		}
+		if len(v.JavaFlags) > 0 {
+			fmt.Printf("JavaFlags     %s\n", strings.Join(v.JavaFlags, " "))
+		}
+		fmt.Printf("Builds        %d\n", len(v.Builds))
+	}
+
+	return nil
+}
+
+// checkVersion warns if the version being downloaded is deprecated or
+// end-of-life, and checks the local Java can run it. javaCheck is one of
+// warn, fail or off. Failing to fetch the version detail isn't fatal.
+func checkVersion(javaHome string, javaCheck string) error {
+	detailer, ok := provider.(papertool.VersionDetailer)
+	if !ok {
+		return nil
+	}
+
+	v, err := detailer.GetVersion(paperProject, paperProjectVersion)
+	if err != nil {
+		return nil
+	}
+
+	msg := v.SupportWarning(time.Now())
+	if msg != "" {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", msg)
+	}
+
+	if javaCheck == "off" || v.JavaMinimum == 0 {
+		return nil
+	}
+
+	rt, err := papertool.DetectJava(javaHome)
+	if err == nil {
+		err = papertool.CheckJava(rt, v)
+	}
+	if err != nil {
+		if javaCheck == "fail" {
+			return fmt.Errorf("-java-check: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "WARNING: %v\n", err)
+	}
+
+	return nil
+}
+
+// artifactChecksum describes the strongest checksum an artifact is
+// verified against.
+func artifactChecksum(app *papertool.Application) string {
+	sums := app.ExpectedChecksums().Known()
+	for _, name := range []string{"sha256", "sha512", "sha1", "md5"} {
+		if sum, ok := sums[name]; ok {
+			return name + " " + sum
+		}
+	}
+
+	return "(no checksum)"
+}
+
+func newProgressReporter(out *os.File) (papertool.ProgressReporter, error) {
+	switch progressMode {
+	case "", "auto":
+		return papertool.AutoProgress(out, quiet), nil
+	case "tty":
+		return papertool.NewTTYProgress(out, quiet), nil
+	case "log":
+		return papertool.NewLogProgress(out, quiet), nil
+	case "json":
+		return papertool.NewJSONProgress(out), nil
+	case "none":
+		return papertool.NopProgress{}, nil
+	}
+
+	return nil, fmt.Errorf("-progress: unknown style '%s'", progressMode)
+}
+
 func newVersionsCmd() *Cmd {
 	rawJson := false
+	detail := false
 
 	cmd := flaggy.NewSubcommand("versions")
 	cmd.Description = "Get Project Versions"
 
 	cmd.Bool(&rawJson, "", "json", "[optional] dump the raw json metadata")
+	cmd.Bool(&detail, "", "detail", "[optional] show support status and Java requirements for -project-version, or every version")
 
 	handler := func(cmd *Cmd) error {
-		versions, err := papertool.GetVersions(serverURL, paperProject)
+		if detail {
+			return showVersionDetail(rawJson)
+		}
+
+		versions, err := provider.ListVersions(paperProject)
 		if err != nil {
 			return err
 		}
@@ -276,3 +817,148 @@ func newVersionsCmd() *Cmd {
 
 	return &Cmd{cmd: cmd, handler: handler}
 }
+
+func newVerifyCmd() *Cmd {
+	dir := ""
+	file := ""
+
+	cmd := flaggy.NewSubcommand("verify")
+	cmd.Description = "Verify existing artifacts against their published checksums"
+
+	cmd.String(&dir, "", "dir", "[optional] verify every jar in this directory")
+	cmd.String(&file, "", "file", "[optional] verify this jar")
+
+	handler := func(cmd *Cmd) error {
+		if dir == "" && file == "" {
+			return fmt.Errorf("one of -dir or -file is required")
+		}
+
+		results := []*papertool.VerifyResult{}
+		if file != "" {
+			results = append(results, papertool.VerifyFileFrom(provider, file))
+		}
+		if dir != "" {
+			r, err := papertool.VerifyDirFrom(provider, dir)
+			if err != nil {
+				return err
+			}
+			results = append(results, r...)
+		}
+
+		bad := 0
+		for _, r := range results {
+			switch r.Status {
+			case papertool.VerifyOK:
+				fmt.Printf("%-8s %s (%s %s build %s)\n", r.Status, r.Path, r.Project, r.Version, r.Build)
+			case papertool.VerifyMismatch:
+				fmt.Printf("%-8s %v (%s %s build %s)\n", r.Status, r.Err, r.Project, r.Version, r.Build)
+				bad++
+			default:
+				fmt.Printf("%-8s %s: %v\n", r.Status, r.Path, r.Err)
+			}
+		}
+
+		if bad > 0 {
+			return fmt.Errorf("%d of %d files failed verification", bad, len(results))
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler, noProject: true}
+}
+
+func newProjectsCmd() *Cmd {
+	rawJson := false
+
+	cmd := flaggy.NewSubcommand("projects")
+	cmd.Description = "List every project on the server"
+
+	cmd.Bool(&rawJson, "", "json", "[optional] dump the raw json metadata")
+
+	handler := func(cmd *Cmd) error {
+		projects, err := provider.ListProjects()
+		if err != nil {
+			return err
+		}
+
+		if rawJson {
+			os.Stdout.Write(projects.Raw())
+			return nil
+		}
+
+		for i, p := range projects.Projects {
+			if i > 0 {
+				fmt.Printf("----------\n")
+			}
+			fmt.Printf("ProjectID     %s\n", p.ID)
+			fmt.Printf("ProjectName   %s\n", p.Name)
+			fmt.Printf("VersionGroups %q\n", p.VersionGroups)
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler, noProject: true}
+}
+
+// checkProject makes sure -project names a real project, suggesting the
+// closest match if it doesn't. It's only called after a lookup came back
+// not found. If the project list can't be fetched the check is skipped and
+// the original error is reported.
+func checkProject() error {
+	projects, err := provider.ListProjects()
+	if err != nil {
+		return nil
+	}
+
+	if projects.Find(paperProject) != nil {
+		return nil
+	}
+
+	ids := projects.IDs()
+	suggestion := closest(paperProject, ids)
+	if suggestion != "" {
+		return fmt.Errorf("unknown project '%s', did you mean '%s'?", paperProject, suggestion)
+	}
+
+	return fmt.Errorf("unknown project '%s' (known projects: %s)", paperProject, strings.Join(ids, ", "))
+}
+
+// closest returns the candidate nearest to s by edit distance, or "" if
+// none is close enough to be a plausible typo.
+func closest(s string, candidates []string) string {
+	best := ""
+	bestDist := len(s)/2 + 1
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(s), strings.ToLower(c))
+		if d < bestDist {
+			best = c
+			bestDist = d
+		}
+	}
+
+	return best
+}
+
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
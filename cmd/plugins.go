@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"github.com/integrii/flaggy"
+	"github.com/tadhunt/papertool"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// plugins is a command group; its handler runs whichever subcommand was
+// used.
+type pluginsCmd struct {
+	server   string
+	platform string
+	limit    int
+	subcmds  []*Cmd
+	hangar   *papertool.Hangar
+}
+
+func newPluginsCmd() *Cmd {
+	p := &pluginsCmd{
+		server: papertool.HangarServer,
+		limit:  25,
+	}
+
+	cmd := flaggy.NewSubcommand("plugins")
+	cmd.Description = "Search for, download and check plugins from Hangar or Modrinth"
+
+	cmd.String(&p.server, "", "hangar", "[optional] URL of the Hangar server (defaults to https://hangar.papermc.io)")
+	cmd.String(&p.platform, "", "platform", "[optional] PAPER, VELOCITY or WATERFALL (defaults to the one matching -project, or PAPER)")
+	cmd.Int(&p.limit, "", "limit", "[optional] maximum number of results to fetch")
+
+	p.subcmds = []*Cmd{
+		p.newSearchCmd(),
+		p.newVersionsCmd(),
+		p.newDownloadCmd(),
+		p.newCheckCmd(),
+		p.newModrinthCmd(),
+	}
+	for _, sub := range p.subcmds {
+		cmd.AttachSubcommand(sub.cmd, 1)
+	}
+
+	handler := func(cmd *Cmd) error {
+		sub := usedCmd(p.subcmds)
+		if sub == nil {
+			return fmt.Errorf("plugins: one of %s is required", subcommandNames(p.subcmds))
+		}
+
+		u, err := url.Parse(p.server)
+		if err != nil {
+			return fmt.Errorf("-hangar: %v", err)
+		}
+		p.hangar = papertool.NewHangar(u)
+
+		if p.platform == "" {
+			p.platform = papertool.HangarPaper
+			if platform, err := papertool.HangarPlatform(paperProject); err == nil {
+				p.platform = platform
+			}
+		}
+		p.platform, err = papertool.HangarPlatform(p.platform)
+		if err != nil {
+			return fmt.Errorf("-platform: %v", err)
+		}
+
+		return sub.handler(sub)
+	}
+
+	return &Cmd{cmd: cmd, handler: handler, noProject: true}
+}
+
+func subcommandNames(cmds []*Cmd) string {
+	names := []string{}
+	for _, cmd := range cmds {
+		names = append(names, cmd.cmd.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func (p *pluginsCmd) newSearchCmd() *Cmd {
+	query := ""
+
+	cmd := flaggy.NewSubcommand("search")
+	cmd.Description = "Search Hangar for plugins"
+
+	cmd.AddPositionalValue(&query, "query", 1, true, "what to search for")
+
+	handler := func(cmd *Cmd) error {
+		projects, err := p.hangar.SearchProjects(query, p.platform, p.limit)
+		if err != nil {
+			return err
+		}
+
+		for i, project := range projects {
+			if i > 0 {
+				fmt.Printf("----------\n")
+			}
+			fmt.Printf("Slug        %s\n", project.Slug)
+			fmt.Printf("Name        %s\n", project.Name)
+			fmt.Printf("Owner       %s\n", project.Owner)
+			fmt.Printf("Category    %s\n", project.Category)
+			fmt.Printf("Downloads   %d\n", project.Downloads)
+			fmt.Printf("Stars       %d\n", project.Stars)
+			fmt.Printf("Updated     %s\n", formatTime(project.LastUpdated, project.LastUpdated.Format("2006-01-02T15:04:05Z07:00")))
+			fmt.Printf("Description %s\n", project.Description)
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler}
+}
+
+func (p *pluginsCmd) newVersionsCmd() *Cmd {
+	slug := ""
+	showChanges := false
+
+	cmd := flaggy.NewSubcommand("versions")
+	cmd.Description = "List a plugin's versions, newest first"
+
+	cmd.AddPositionalValue(&slug, "plugin", 1, true, "the plugin's Hangar slug")
+	cmd.Bool(&showChanges, "", "changes", "[optional] show each version's changelog")
+
+	handler := func(cmd *Cmd) error {
+		versions, err := p.hangar.ListVersions(slug, p.platform, paperProjectVersion, p.limit)
+		if err != nil {
+			return err
+		}
+
+		if len(versions) == 0 {
+			return fmt.Errorf("%s: no versions for %s %s", slug, p.platform, paperProjectVersion)
+		}
+
+		for i, v := range versions {
+			if i > 0 {
+				fmt.Printf("----------\n")
+			}
+			fmt.Printf("Version   %s\n", v.Name)
+			fmt.Printf("Channel   %s\n", v.Channel)
+			fmt.Printf("Created   %s\n", formatTime(v.CreatedAt, v.CreatedAt.Format("2006-01-02T15:04:05Z07:00")))
+			fmt.Printf("Platforms %s\n", strings.Join(v.Platforms(), ", "))
+			if deps := v.PlatformDependencies[p.platform]; len(deps) > 0 {
+				fmt.Printf("Supports  %s\n", strings.Join(deps, ", "))
+			}
+			if d, ok := v.Downloads[p.platform]; ok {
+				fmt.Printf("File      %s sha256 %s\n", d.FileName, d.Sha256)
+			}
+			if showChanges && v.Description != "" {
+				fmt.Printf("%s\n", v.Description)
+			}
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler}
+}
+
+func (p *pluginsCmd) newDownloadCmd() *Cmd {
+	slug := ""
+	version := ""
+	dir := "plugins"
+	replace := false
+
+	cmd := flaggy.NewSubcommand("download")
+	cmd.Description = "Download a plugin into a server's plugins directory"
+
+	cmd.AddPositionalValue(&slug, "plugin", 1, true, "the plugin's Hangar slug")
+	cmd.String(&version, "", "plugin-version", "[optional] plugin version to download (defaults to the newest for -platform and -project-version)")
+	cmd.String(&dir, "", "dir", "[optional] directory to download into")
+	cmd.Bool(&replace, "", "replace", "[optional] replace the jar if it already exists, and remove other jars carrying the same plugin (e.g. the previous version)")
+
+	handler := func(cmd *Cmd) error {
+		versions, err := p.hangar.ListVersions(slug, p.platform, paperProjectVersion, p.limit)
+		if err != nil {
+			return err
+		}
+
+		var v *papertool.HangarVersion
+		for _, candidate := range versions {
+			if version == "" || candidate.Name == version {
+				v = candidate
+				break
+			}
+		}
+		if v == nil {
+			if version != "" {
+				return fmt.Errorf("-plugin-version: %s has no version '%s' for %s %s", slug, version, p.platform, paperProjectVersion)
+			}
+			return fmt.Errorf("%s: no versions for %s %s", slug, p.platform, paperProjectVersion)
+		}
+
+		st, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("%s: %v", dir, err)
+		}
+		if !st.IsDir() {
+			return fmt.Errorf("%s: is not a directory", dir)
+		}
+
+		reporter, err := newProgressReporter(os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		opts := papertool.DefaultDownloadOptions()
+		opts.Replace = replace
+		opts.Progress = reporter
+
+		artifact, err := v.Artifact(p.platform)
+		if err != nil {
+			return err
+		}
+
+		replaced, err := papertool.InstallPlugin(artifact, dir, opts)
+		if err != nil {
+			return err
+		}
+		for _, path := range replaced {
+			fmt.Printf("Removed %s\n", path)
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler}
+}
+
+func (p *pluginsCmd) newCheckCmd() *Cmd {
+	dir := "plugins"
+	offline := false
+
+	cmd := flaggy.NewSubcommand("check")
+	cmd.Description = "Check a plugins directory against the -project-version about to be installed"
+
+	cmd.String(&dir, "", "dir", "[optional] plugins directory to check")
+	cmd.Bool(&offline, "", "offline", "[optional] don't look for newer versions on Hangar")
+
+	handler := func(cmd *Cmd) error {
+		if paperProjectVersion == "" {
+			return fmt.Errorf("-project-version is required")
+		}
+
+		plugins, errs, err := papertool.ScanPlugins(dir)
+		if err != nil {
+			return err
+		}
+
+		hangar := p.hangar
+		if offline {
+			hangar = nil
+		}
+
+		reports := papertool.CheckPlugins(plugins, paperProjectVersion, hangar, p.platform)
+
+		bad := 0
+		for _, r := range reports {
+			status := "OK"
+			if !r.Compatible() {
+				status = "PROBLEM"
+				bad++
+			}
+
+			fmt.Printf("%-8s %s %s (%s)\n", status, r.Plugin.Name, r.Plugin.Version, r.Plugin.Path)
+			for _, problem := range r.Problems {
+				fmt.Printf("         problem: %s\n", problem)
+			}
+			for _, warning := range r.Warnings {
+				fmt.Printf("         warning: %s\n", warning)
+			}
+			switch {
+			case r.LatestErr != nil:
+				fmt.Printf("         hangar:  %v\n", r.LatestErr)
+			case r.UpdateAvailable():
+				fmt.Printf("         update:  %s is available on Hangar\n", r.Latest)
+			}
+		}
+
+		for _, err := range errs {
+			fmt.Printf("%-8s %v\n", "SKIPPED", err)
+		}
+
+		if bad > 0 {
+			return fmt.Errorf("%d of %d plugins have problems with %s", bad, len(reports), paperProjectVersion)
+		}
+
+		return nil
+	}
+
+	return &Cmd{cmd: cmd, handler: handler}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/integrii/flaggy"
+	"github.com/tadhunt/papertool"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func newRunCmd() *Cmd {
+	dir := ""
+	java := ""
+	memory := ""
+	restartAt := ""
+	restartEvery := time.Duration(0)
+	noUpdate := false
+
+	cmd := flaggy.NewSubcommand("run")
+	cmd.Description = "Run and supervise a server set up by init, restarting it on crashes and updating it on planned restarts (SIGHUP restarts it)"
+
+	cmd.String(&dir, "", "dir", "[required] server directory set up by init")
+	cmd.String(&java, "", "java", "[optional] java binary to run instead of the one recorded by init")
+	cmd.String(&memory, "", "memory", "[optional] heap size to run with instead of the one recorded by init, e.g. 4G")
+	cmd.String(&restartAt, "", "restart-at", "[optional] restart every day at this local time, e.g. 04:00")
+	cmd.Duration(&restartEvery, "", "restart-every", "[optional] restart this long after each start, e.g. 12h")
+	cmd.Bool(&noUpdate, "", "no-update", "[optional] don't install newer builds on planned restarts")
+
+	handler := func(cmd *Cmd) error {
+		if dir == "" {
+			return fmt.Errorf("-dir is required")
+		}
+
+		_, err := papertool.ReadInstallRecord(dir)
+		if err != nil {
+			return fmt.Errorf("-dir: %v (was it set up with init?)", err)
+		}
+
+		if memory != "" {
+			err = papertool.CheckMemory(memory)
+			if err != nil {
+				return fmt.Errorf("-memory: %v", err)
+			}
+		}
+
+		if restartAt != "" && restartEvery != 0 {
+			return fmt.Errorf("-restart-at can't be used with -restart-every")
+		}
+
+		r := papertool.NewRunner(dir)
+
+		r.Command = func() ([]string, error) {
+			rec, err := papertool.ReadInstallRecord(dir)
+			if err != nil {
+				return nil, err
+			}
+			if java != "" {
+				rec.Java = java
+			}
+			if memory != "" {
+				rec.Memory = memory
+			}
+			return rec.Command(), nil
+		}
+
+		switch {
+		case restartAt != "":
+			at, err := time.Parse("15:04", restartAt)
+			if err != nil {
+				return fmt.Errorf("-restart-at: want HH:MM, got '%s'", restartAt)
+			}
+			r.Schedule = func(now time.Time) time.Time {
+				next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+				if !next.After(now) {
+					next = next.AddDate(0, 0, 1)
+				}
+				return next
+			}
+		case restartEvery < 0:
+			return fmt.Errorf("-restart-every: must be positive")
+		case restartEvery > 0:
+			r.Schedule = func(now time.Time) time.Time {
+				return now.Add(restartEvery)
+			}
+		}
+
+		if !noUpdate {
+			r.Update = func() error {
+				return updateInstall(dir, r.Logf)
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+		go func() {
+			for range hup {
+				r.Restart()
+			}
+		}()
+
+		return r.Run(ctx)
+	}
+
+	return &Cmd{cmd: cmd, handler: handler, noProject: true}
+}
+
+// updateInstall installs the newest build of the version recorded in dir,
+// from the provider and server it was installed from.
+func updateInstall(dir string, logf func(format string, args ...interface{})) error {
+	rec, err := papertool.ReadInstallRecord(dir)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(rec.Server)
+	if err != nil {
+		return err
+	}
+
+	p, err := papertool.NewProvider(rec.Provider, u)
+	if err != nil {
+		return err
+	}
+
+	reporter, err := newProgressReporter(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	opts := papertool.DefaultDownloadOptions()
+	opts.Progress = reporter
+	opts.Warnf = logf
+
+	updated, changed, err := papertool.UpdateInstall(p, dir, channel, opts)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		logf("updated %s %s from build %s to %s", rec.Project, rec.Version, rec.Build, updated.Build)
+	} else {
+		logf("%s %s build %s is the newest", rec.Project, rec.Version, rec.Build)
+	}
+
+	return nil
+}
@@ -3,10 +3,14 @@ package papertool
 import (
 	"context"
 	"fmt"
-	"golang.org/x/text/number"
 	"github.com/tadhunt/go-dl-stream/v2"
+	"hash"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 	"github.com/tadhunt/logger"
 )
@@ -23,40 +27,324 @@ func (l *Logger) Errorf(format string, args ...interface{}) {
 	l.log.Errorf(format, args)
 }
 
+type DownloadOptions struct {
+	Replace bool
+
+	// Output, if set, is the path to write to instead of the artifact's
+	// name in dstdir.
+	Output string
+
+	// Progress receives progress events. If nil, AutoProgress(os.Stdout,
+	// Quiet) is used.
+	Progress ProgressReporter
+	Quiet    bool
+
+	// RateLimit, if set, throttles the download. Share one RateLimiter
+	// between downloads to cap their combined bandwidth.
+	RateLimit *RateLimiter
+
+	// History records each build downloaded into dstdir in its install
+	// history, so later updates can say what changed since. It's ignored
+	// when Output is set.
+	History bool
+
+	// Warnf prints warnings, e.g. when the history can't be written.
+	// Defaults to stderr.
+	Warnf func(format string, args ...interface{})
+}
+
+func DefaultDownloadOptions() *DownloadOptions {
+	return &DownloadOptions{}
+}
+
+func (opts *DownloadOptions) progress() ProgressReporter {
+	if opts.Progress != nil {
+		return opts.Progress
+	}
+
+	return AutoProgress(os.Stdout, opts.Quiet)
+}
+
+func (opts *DownloadOptions) warnf(format string, args ...interface{}) {
+	if opts.Warnf != nil {
+		opts.Warnf(format, args...)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "WARNING: "+format+"\n", args...)
+}
+
 func Download(serverURL *url.URL, project string, version string, build string, artifact *Artifact, dstdir string, replace bool, quiet bool) error {
+	opts := DefaultDownloadOptions()
+	opts.Replace = replace
+	opts.Quiet = quiet
+	opts.History = true
+
+	return DownloadOpts(serverURL, project, version, build, artifact, dstdir, opts)
+}
+
+func DownloadOpts(serverURL *url.URL, project string, version string, build string, artifact *Artifact, dstdir string, opts *DownloadOptions) error {
+	return downloadBuild(serverURL, project, version, build, artifact, dstdir, opts, opts.progress())
+}
+
+// downloadBuild is the work shared by DownloadOpts and DownloadBatch:
+// check the build against KnownChecksums, download it unless it's already
+// current, and record it in the install history if opts asks for that.
+// Failing to record the history doesn't fail the download.
+func downloadBuild(serverURL *url.URL, project string, version string, build string, artifact *Artifact, dstdir string, opts *DownloadOptions, progress ProgressReporter) error {
+	err := pinChecksum(project, version, build, artifact)
+	if err != nil {
+		return err
+	}
+
+	src, dst, current, err := prepareDownload(serverURL, project, version, build, artifact, dstdir, opts)
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("%s to %s", src, dst)
+
+	if current {
+		progress.Skipped(&Progress{Name: msg}, "already up to date")
+		return nil
+	}
+
+	sw := NewStatusWriterOpts(msg, &StatusWriterOptions{
+		Expected:  artifactSize(artifact),
+		Reporter:  progress,
+		RateLimit: opts.RateLimit,
+	})
+
+	err = download(src, dst, artifact, sw)
+	if err != nil {
+		return err
+	}
+
+	if !opts.History || opts.Output != "" {
+		return nil
+	}
+
+	err = AppendHistory(dstdir, &HistoryEntry{
+		Time:     time.Now().UTC(),
+		Project:  project,
+		Version:  version,
+		Build:    build,
+		Artifact: filepath.Base(dst),
+		Sha256:   sw.Sha256(),
+		URL:      src,
+	})
+	if err != nil {
+		opts.warnf("%s: recording history: %v", dst, err)
+	}
+
+	return nil
+}
+
+// DownloadPlugin fetches a plugin jar into dir, usually a server's
+// plugins/ directory, verifying it against whatever checksums the
+// artifact carries. Plugins aren't server builds, so unlike DownloadOpts
+// nothing is pinned in KnownChecksums or recorded in the install history.
+// The artifact must carry a URL.
+func DownloadPlugin(artifact *Artifact, dir string, opts *DownloadOptions) error {
+	if artifact == nil || artifact.Application == nil || artifact.Application.URL == nil || *artifact.Application.URL == "" {
+		return fmt.Errorf("bad artifact")
+	}
+
+	src, dst, current, err := prepareDownload(nil, "", "", "", artifact, dir, opts)
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("%s to %s", src, dst)
+
+	if current {
+		opts.progress().Skipped(&Progress{Name: msg}, "already up to date")
+		return nil
+	}
+
+	sw := NewStatusWriterOpts(msg, &StatusWriterOptions{
+		Expected:  artifactSize(artifact),
+		Reporter:  opts.progress(),
+		RateLimit: opts.RateLimit,
+	})
+
+	return download(src, dst, artifact, sw)
+}
+
+// DownloadTo streams the artifact to w, verifying its checksums once the
+// whole thing has been written. The artifact must carry a URL. On error w
+// may have received some or all of the data, and it's up to the caller to
+// discard it.
+//
+// DownloadTo doesn't know which build the artifact belongs to, so it isn't
+// checked against KnownChecksums; use DownloadToOpts for that.
+func DownloadTo(ctx context.Context, artifact *Artifact, w io.Writer) error {
+	opts := DefaultDownloadOptions()
+	opts.Progress = NopProgress{}
+
+	return DownloadToOpts(ctx, "", "", "", artifact, w, opts)
+}
+
+// DownloadToOpts is DownloadTo for a build, checking it against
+// KnownChecksums like DownloadOpts does before anything is fetched.
+func DownloadToOpts(ctx context.Context, project string, version string, build string, artifact *Artifact, w io.Writer, opts *DownloadOptions) error {
 	if artifact == nil || artifact.Application == nil || artifact.Application.Name == nil {
 		return fmt.Errorf("bad artifact")
 	}
 
+	err := pinChecksum(project, version, build, artifact)
+	if err != nil {
+		return err
+	}
+
+	src := String(artifact.Application.URL)
+	if artifact.Application.URL == nil || src == "" {
+		return fmt.Errorf("%s: artifact has no download url", String(artifact.Application.Name))
+	}
+
+	sw := NewStatusWriterOpts(src, &StatusWriterOptions{
+		Expected:  artifactSize(artifact),
+		Reporter:  opts.progress(),
+		RateLimit: opts.RateLimit,
+	})
+
+	err = downloadTo(ctx, src, w, sw)
+	if err == nil {
+		err = verify(src, artifact, sw)
+	}
+	if err != nil {
+		sw.Fail(err)
+		return err
+	}
+
+	sw.Finish()
+
+	return nil
+}
+
+func downloadTo(ctx context.Context, src string, w io.Writer, sw *StatusWriter) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 64*1024))
+		return statusError(src, response, body)
+	}
+
+	if sw.expected > 0 && response.ContentLength >= 0 && response.ContentLength != sw.expected {
+		return fmt.Errorf("%s: Content-Length %d disagrees with advertised size %d", src, response.ContentLength, sw.expected)
+	}
+
+	// Tee through the StatusWriter first so the size check and rate limit
+	// apply before anything reaches w.
+	_, err = io.Copy(w, io.TeeReader(response.Body, sw))
+	if err != nil {
+		return err
+	}
+
+	return sw.Check()
+}
+
+// artifactURL returns where to fetch the artifact from.
+func artifactURL(serverURL *url.URL, project string, version string, build string, artifact *Artifact) string {
 	// v3 builds expose a direct CDN URL on the artifact; prefer it. Fall
 	// back to the legacy v2 path for any caller still constructing
 	// Artifacts by hand.
 	src := String(artifact.Application.URL)
-	if src == "" {
+	if artifact.Application.URL == nil || src == "" {
 		src = fmt.Sprintf("%s/v2/projects/%s/versions/%s/builds/%s/downloads/%s", serverURL.String(), project, version, build, String(artifact.Application.Name))
 	}
-	dst := fmt.Sprintf("%s/%s", dstdir, String(artifact.Application.Name))
 
-	_, err := os.Stat(dst)
+	return src
+}
+
+// pinChecksum checks the build's sha256 against KnownChecksums, if there's
+// a database open.
+func pinChecksum(project string, version string, build string, artifact *Artifact) error {
+	if KnownChecksums == nil || artifact == nil || artifact.Application == nil || artifact.Application.Sha256 == nil {
+		return nil
+	}
+
+	return KnownChecksums.Check(project, version, build, *artifact.Application.Sha256)
+}
+
+// prepareDownload resolves the source URL and destination path for an
+// artifact. If the destination already exists and matches the artifact's
+// checksums, current is returned true and there's nothing to do. Otherwise an
+// existing file is removed if opts.Replace is set.
+func prepareDownload(serverURL *url.URL, project string, version string, build string, artifact *Artifact, dstdir string, opts *DownloadOptions) (src string, dst string, current bool, err error) {
+	if artifact == nil || artifact.Application == nil || artifact.Application.Name == nil {
+		return "", "", false, fmt.Errorf("bad artifact")
+	}
+
+	src = artifactURL(serverURL, project, version, build, artifact)
+
+	dst = opts.Output
+	if dst == "" {
+		name := String(artifact.Application.Name)
+		err = CheckFileName(name)
+		if err != nil {
+			return "", "", false, err
+		}
+		dst = filepath.Join(dstdir, filepath.Base(name))
+	}
+
+	_, err = os.Stat(dst)
 	if err == nil {
-		if !replace {
-			return fmt.Errorf("%s: already exists and -replace not specified", dst)
+		current, err := ArtifactCurrent(dst, artifact)
+		if err != nil {
+			return "", "", false, err
+		}
+		if current {
+			return src, dst, true, nil
+		}
+
+		if !opts.Replace {
+			return "", "", false, fmt.Errorf("%s: already exists and -replace not specified", dst)
 		}
 
 		err = os.Remove(dst)
 		if err != nil {
-			return fmt.Errorf("remove %s: %v", dst, err)
+			return "", "", false, fmt.Errorf("remove %s: %v", dst, err)
 		}
 	} else {
 		if !os.IsNotExist(err) {
-			return fmt.Errorf("stat %s: %v", dst, err)
+			return "", "", false, fmt.Errorf("stat %s: %v", dst, err)
 		}
 	}
 
-	msg := fmt.Sprintf("%s to %s", src, dst)
+	return src, dst, false, nil
+}
+
+// ArtifactCurrent reports whether the file at path exists and matches
+// every checksum published for artifact. With no checksums to compare
+// against it's never current.
+func ArtifactCurrent(path string, artifact *Artifact) (bool, error) {
+	expected := artifact.Application.ExpectedChecksums()
+	if len(expected.Known()) == 0 {
+		return false, nil
+	}
+
+	actual, err := HashFileChecksums(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
 
-	sw := NewStatusWriter(msg, quiet)
+	return expected.Verify(path, actual) == nil, nil
+}
 
+func download(src string, dst string, artifact *Artifact, sw *StatusWriter) error {
 	log := logger.NewCompatLogWriter(logger.LogLevel_DEBUG)
 
 	l := &Logger{
@@ -65,25 +353,52 @@ func Download(serverURL *url.URL, project string, version string, build string,
 	options := dlstream.DefaultOptions()
 	options.Logger = l
 
-	err = dlstream.DownloadStreamOpts(context.Background(), src, dst, sw, options)
+	// dlstream doesn't hand back the response, so the advertised size is
+	// enforced on the stream itself: sw fails writes past it and Check
+	// catches a short body.
+	err := dlstream.DownloadStreamOpts(context.Background(), src, dst, sw, options)
+	if err == nil {
+		err = sw.Check()
+	}
+	if err == nil {
+		err = verify(dst, artifact, sw)
+	}
 	if err != nil {
+		sw.Fail(err)
 		return err
 	}
 
-	elapsed := time.Now().Sub(sw.start)
-	kbps := float64(sw.total) / 1000.0 / elapsed.Seconds()
+	sw.Finish()
+
+	return nil
+}
 
-	hash := fmt.Sprintf("%x", sw.sha256.Sum(nil))
-	sw.p.Printf("%s%sDownloaded %s to %s %v bytes (%v KB/s) sha256 %s\n", EraseLine, SOL, src, dst, number.Decimal(sw.total), sw.format(kbps), hash)
+// verify checks what passed through sw against every checksum published
+// for the artifact that papertool knows how to compute. name is only used
+// in the error message.
+func verify(name string, artifact *Artifact, sw *StatusWriter) error {
+	return artifact.Application.ExpectedChecksums().Verify(name, sw.Checksums())
+}
 
-	expected := String(artifact.Application.Sha256)
-	if expected == "" {
-		return nil
+// CheckFileName fails unless name is a plain file name. Artifact names
+// come from the server, and one like "../../.bashrc" mustn't be allowed to
+// write outside the directory it's downloaded into.
+func CheckFileName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return fmt.Errorf("bad file name %q", name)
 	}
 
-	if hash != expected {
-		return fmt.Errorf("%s: sha256 mismatch %s expected %s", dst, hash, expected)
+	return nil
+}
+
+func artifactSize(artifact *Artifact) int64 {
+	if artifact == nil || artifact.Application == nil || artifact.Application.Size == nil {
+		return 0
 	}
 
-	return nil
+	return *artifact.Application.Size
+}
+
+func hexSum(h hash.Hash) string {
+	return fmt.Sprintf("%x", h.Sum(nil))
 }
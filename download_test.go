@@ -0,0 +1,198 @@
+package papertool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadTo(t *testing.T) {
+	jar := []byte("not really a jar")
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/jar/":     serveJar(jar),
+		"/missing/": serveStatus(http.StatusNotFound, "not found"),
+	})
+
+	w := &bytes.Buffer{}
+	err := DownloadTo(context.Background(), jarArtifact(server.String(), "paper.jar", jar, ""), w)
+	if err != nil {
+		t.Fatalf("1: %v", err)
+	}
+	if !bytes.Equal(w.Bytes(), jar) {
+		t.Fatalf("2: got %q", w.Bytes())
+	}
+
+	missing := jarArtifact(server.String(), "paper.jar", jar, "")
+	src := server.String() + "/missing/paper.jar"
+	missing.Application.URL = &src
+	err = DownloadTo(context.Background(), missing, &bytes.Buffer{})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("3: expected ErrNotFound got %v", err)
+	}
+
+	noURL := jarArtifact(server.String(), "paper.jar", jar, "")
+	noURL.Application.URL = nil
+	err = DownloadTo(context.Background(), noURL, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("4: downloaded without a url")
+	}
+
+	err = DownloadTo(context.Background(), &Artifact{}, &bytes.Buffer{})
+	if err == nil {
+		t.Fatalf("5: downloaded a bad artifact")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = DownloadTo(ctx, jarArtifact(server.String(), "paper.jar", jar, ""), &bytes.Buffer{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("6: expected context.Canceled got %v", err)
+	}
+}
+
+func TestDownloadToOptsPinned(t *testing.T) {
+	jar := []byte("not really a jar")
+
+	requests := 0
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/jar/": func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			serveJar(jar)(w, r)
+		},
+	})
+
+	db, _, _ := testChecksumDB(t, PinStrict)
+	saved := KnownChecksums
+	KnownChecksums = db
+	t.Cleanup(func() { KnownChecksums = saved })
+
+	artifact := jarArtifact(server.String(), "paper.jar", jar, "")
+	sha := artifact.Application.Checksums["sha256"]
+	artifact.Application.Sha256 = &sha
+
+	opts := &DownloadOptions{Progress: NopProgress{}}
+	err := DownloadToOpts(context.Background(), "paper", "1.21.4", "232", artifact, &bytes.Buffer{}, opts)
+	if err != nil {
+		t.Fatalf("1: %v", err)
+	}
+	if db.Lookup("paper", "1.21.4", "232") == nil {
+		t.Fatalf("2: build wasn't recorded")
+	}
+
+	// The same build reporting a different checksum is refused before
+	// anything is fetched.
+	changed := "0000"
+	artifact.Application.Sha256 = &changed
+	requests = 0
+	w := &bytes.Buffer{}
+	err = DownloadToOpts(context.Background(), "paper", "1.21.4", "232", artifact, w, opts)
+
+	var cerr *ChecksumChangedError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("3: expected *ChecksumChangedError got %v", err)
+	}
+	if requests != 0 || w.Len() != 0 {
+		t.Fatalf("4: fetched %d times, wrote %d bytes", requests, w.Len())
+	}
+}
+
+func TestCheckFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"paper-1.21.4-232.jar", true},
+		{"..jar", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../evil.jar", false},
+		{"plugins/evil.jar", false},
+		{"/etc/evil.jar", false},
+		{`..\evil.jar`, false},
+	}
+
+	for _, test := range tests {
+		err := CheckFileName(test.name)
+		if (err == nil) != test.ok {
+			t.Fatalf("1: %q: got %v", test.name, err)
+		}
+	}
+}
+
+func TestDownloadPluginBadArtifact(t *testing.T) {
+	jar := []byte("not really a jar")
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/jar/": serveJar(jar),
+	})
+
+	opts := &DownloadOptions{Progress: NopProgress{}}
+
+	noURL := jarArtifact(server.String(), "plugin.jar", jar, "")
+	noURL.Application.URL = nil
+	err := DownloadPlugin(noURL, t.TempDir(), opts)
+	if err == nil {
+		t.Fatalf("1: downloaded without a url")
+	}
+
+	// Names that would land outside dir are refused before anything is
+	// fetched.
+	dir := filepath.Join(t.TempDir(), "plugins")
+	err = os.Mkdir(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"../evil.jar", ".."} {
+		err = DownloadPlugin(jarArtifact(server.String(), name, jar, ""), dir, opts)
+		if err == nil {
+			t.Fatalf("2: %q: expected error", name)
+		}
+	}
+	_, err = os.Stat(filepath.Join(filepath.Dir(dir), "evil.jar"))
+	if !os.IsNotExist(err) {
+		t.Fatalf("3: escaped the plugins directory: %v", err)
+	}
+
+	err = DownloadPlugin(jarArtifact(server.String(), "plugin.jar", jar, ""), dir, opts)
+	if err != nil {
+		t.Fatalf("4: %v", err)
+	}
+}
+
+func TestArtifactCurrent(t *testing.T) {
+	jar := []byte("not really a jar")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "paper.jar")
+
+	artifact := jarArtifact("http://localhost", "paper.jar", jar, "")
+
+	current, err := ArtifactCurrent(path, artifact)
+	if err != nil || current {
+		t.Fatalf("1: missing file: got %v %v", current, err)
+	}
+
+	err = os.WriteFile(path, jar, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err = ArtifactCurrent(path, artifact)
+	if err != nil || !current {
+		t.Fatalf("2: matching file: got %v %v", current, err)
+	}
+
+	current, err = ArtifactCurrent(path, jarArtifact("http://localhost", "paper.jar", jar, "sha1"))
+	if err != nil || current {
+		t.Fatalf("3: wrong sha1: got %v %v", current, err)
+	}
+
+	// Nothing to check against is never current.
+	artifact.Application.Checksums = nil
+	current, err = ArtifactCurrent(path, artifact)
+	if err != nil || current {
+		t.Fatalf("4: no checksums: got %v %v", current, err)
+	}
+}
@@ -0,0 +1,154 @@
+package papertool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinels for errors.Is. Each typed error below matches one of these, so
+// callers that only care about the kind of failure needn't use errors.As.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrRateLimited = errors.New("rate limited")
+	ErrServer      = errors.New("server error")
+	ErrDecode      = errors.New("decode error")
+)
+
+// NotFoundError is returned for a 404, e.g. an unknown project, version
+// or build.
+type NotFoundError struct {
+	URL     string
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s: not found: %s", e.URL, e.Message)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// RateLimitedError is returned for a 429. RetryAfter is how long the
+// server asked us to wait, or 0 if it didn't say.
+type RateLimitedError struct {
+	URL        string
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s: rate limited, retry after %v: %s", e.URL, e.RetryAfter, e.Message)
+	}
+
+	return fmt.Sprintf("%s: rate limited: %s", e.URL, e.Message)
+}
+
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// ServerError is returned for any other unsuccessful status. Is(ErrServer)
+// is only true for 5xx; anything else is the request's fault.
+type ServerError struct {
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("%s: status %d: %s", e.URL, e.StatusCode, e.Message)
+}
+
+func (e *ServerError) Is(target error) bool {
+	return target == ErrServer && e.StatusCode >= 500
+}
+
+// DecodeError is returned when a response isn't the shape we expected.
+// Malformed JSON is reported as a *MetadataSyntaxError instead.
+type DecodeError struct {
+	URL string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: decode: %v", e.URL, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+func (e *MetadataSyntaxError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+// statusError converts an unsuccessful response into one of the typed
+// errors above, pulling the message out of the v3 JSON error body when
+// there is one.
+func statusError(src string, response *http.Response, body []byte) error {
+	msg := errorMessage(body)
+
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return &NotFoundError{URL: src, Message: msg}
+	case response.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitedError{URL: src, Message: msg, RetryAfter: retryAfter(response.Header.Get("Retry-After"))}
+	}
+
+	return &ServerError{URL: src, StatusCode: response.StatusCode, Message: msg}
+}
+
+// errorMessage extracts a message from a v3 error body, which looks like
+// {"error": "...", "message": "..."}. Anything else is returned as text.
+func errorMessage(body []byte) string {
+	v3 := struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}{}
+
+	err := json.Unmarshal(body, &v3)
+	if err == nil {
+		if v3.Message != "" {
+			return v3.Message
+		}
+		if v3.Error != "" {
+			return v3.Error
+		}
+	}
+
+	return strings.TrimSpace(string(body))
+}
+
+// retryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	secs, err := strconv.Atoi(value)
+	if err == nil {
+		return time.Duration(secs) * time.Second
+	}
+
+	t, err := http.ParseTime(value)
+	if err == nil {
+		d := time.Until(t)
+		if d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
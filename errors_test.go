@@ -0,0 +1,63 @@
+package papertool
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestFetchErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/projects/paper/versions/0.0/builds/1":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","message":"Version not found."}`))
+		case "/v3/projects/paper/versions/1.0/builds/1":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/v3/projects/paper/versions/2.0/builds/1":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("upstream went away"))
+		case "/v3/projects/paper/versions/3.0/builds/1":
+			w.Write([]byte(`{"id": "x"}`))
+		case "/v3/projects/paper/versions/4.0/builds/1":
+			w.Write([]byte(`{"id": `))
+		}
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+
+	_, err := GetBuild(u, "paper", "0.0", "1")
+	var nf *NotFoundError
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &nf) || nf.Message != "Version not found." {
+		t.Fatalf("1: expected NotFoundError, got %v", err)
+	}
+
+	_, err = GetBuild(u, "paper", "1.0", "1")
+	var rl *RateLimitedError
+	if !errors.Is(err, ErrRateLimited) || !errors.As(err, &rl) || rl.RetryAfter != 30*time.Second {
+		t.Fatalf("2: expected RateLimitedError, got %v", err)
+	}
+
+	_, err = GetBuild(u, "paper", "2.0", "1")
+	var se *ServerError
+	if !errors.Is(err, ErrServer) || !errors.As(err, &se) || se.StatusCode != http.StatusBadGateway || se.Message != "upstream went away" {
+		t.Fatalf("3: expected ServerError, got %v", err)
+	}
+
+	_, err = GetBuild(u, "paper", "3.0", "1")
+	var de *DecodeError
+	if !errors.Is(err, ErrDecode) || !errors.As(err, &de) {
+		t.Fatalf("4: expected DecodeError, got %v", err)
+	}
+
+	_, err = GetBuild(u, "paper", "4.0", "1")
+	var me *MetadataSyntaxError
+	if !errors.Is(err, ErrDecode) || !errors.As(err, &me) {
+		t.Fatalf("5: expected MetadataSyntaxError, got %v", err)
+	}
+}
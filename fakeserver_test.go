@@ -0,0 +1,52 @@
+package papertool
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeServer serves routes, keyed by path, for the length of the test.
+func fakeServer(t *testing.T, routes map[string]http.HandlerFunc) *url.URL {
+	mux := http.NewServeMux()
+	for path, h := range routes {
+		mux.HandleFunc(path, h)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+// serveJSON answers with body. "$SERVER" in it is replaced with the fake
+// server's URL, for APIs that hand out absolute links.
+func serveJSON(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.ReplaceAll(body, "$SERVER", "http://"+r.Host)))
+	}
+}
+
+// serveStatus answers with status and body.
+func serveStatus(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+// serveJar answers with jar and its Content-Length.
+func serveJar(jar []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(jar)))
+		w.Write(jar)
+	}
+}
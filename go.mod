@@ -3,9 +3,11 @@ module github.com/tadhunt/papertool
 go 1.24.2
 
 require (
+	github.com/klauspost/compress v1.19.2
 	github.com/tadhunt/go-dl-stream/v2 v2.0.3
 	github.com/tadhunt/logger v0.0.0-20250303180812-6aad7c71b986
 	golang.org/x/text v0.11.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,363 @@
+package papertool
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+ * Plugins come from PaperMC's Hangar repository:
+ *
+ *   GET https://hangar.papermc.io/api/v1/projects?q=${QUERY}&platform=PAPER&limit=25
+ *   {
+ *     "pagination": { "limit": 25, "offset": 0, "count": 3 },
+ *     "result": [
+ *       {
+ *         "name":        "ViaVersion",
+ *         "namespace":   { "owner": "ViaVersion", "slug": "ViaVersion" },
+ *         "description": "...",
+ *         "category":    "protocol",
+ *         "lastUpdated": "2025-01-10T12:00:00.000Z",
+ *         "stats":       { "downloads": 123456, "stars": 321 }
+ *       },
+ *       ...
+ *     ]
+ *   }
+ *
+ *   GET https://hangar.papermc.io/api/v1/projects/${SLUG}/versions?platform=PAPER&platformVersion=1.21.4&limit=25
+ *   {
+ *     "pagination": { ... },
+ *     "result": [
+ *       {
+ *         "name":        "5.2.1",
+ *         "createdAt":   "2025-01-10T12:00:00.000Z",
+ *         "description": "changelog",
+ *         "channel":     { "name": "Release" },
+ *         "downloads": {
+ *           "PAPER": {
+ *             "fileInfo":    { "name": "ViaVersion-5.2.1.jar", "sizeBytes": 5242880, "sha256Hash": "..." },
+ *             "externalUrl": null,
+ *             "downloadUrl": "https://hangarcdn.papermc.io/plugins/ViaVersion/ViaVersion/versions/5.2.1/PAPER/ViaVersion-5.2.1.jar"
+ *           }
+ *         },
+ *         "platformDependencies": { "PAPER": ["1.8-1.21.4"] },
+ *         "pluginDependencies": {
+ *           "PAPER": [{ "name": "ViaBackwards", "required": false, "platform": "PAPER" }]
+ *         }
+ *       },
+ *       ...
+ *     ]
+ *   }
+ *
+ * Versions come back newest first. Some are only hosted elsewhere, in
+ * which case downloadUrl is null and externalUrl says where.
+ */
+
+const (
+	HangarServer = "https://hangar.papermc.io"
+
+	HangarPaper     = "PAPER"
+	HangarVelocity  = "VELOCITY"
+	HangarWaterfall = "WATERFALL"
+)
+
+// HangarPlatform returns the Hangar platform for a papermc.io project,
+// e.g. "paper" is PAPER.
+func HangarPlatform(project string) (string, error) {
+	platform := strings.ToUpper(project)
+	switch platform {
+	case HangarPaper, HangarVelocity, HangarWaterfall:
+		return platform, nil
+	}
+
+	return "", fmt.Errorf("unknown platform '%s', must be one of %s, %s or %s", project, HangarPaper, HangarVelocity, HangarWaterfall)
+}
+
+type HangarProject struct {
+	Name        string
+	Owner       string
+	Slug        string
+	Description string
+	Category    string
+	Downloads   int64
+	Stars       int64
+	LastUpdated time.Time
+}
+
+type HangarVersion struct {
+	Slug        string // the project the version belongs to
+	Name        string
+	Channel     string
+	Description string
+	CreatedAt   time.Time
+
+	// The following are keyed by platform.
+	Downloads            map[string]*HangarDownload
+	PlatformDependencies map[string][]string
+	PluginDependencies   map[string][]*HangarDependency
+}
+
+type HangarDownload struct {
+	FileName    string
+	Size        int64
+	Sha256      string
+	URL         string
+	ExternalURL string
+}
+
+type HangarDependency struct {
+	Name        string
+	Required    bool
+	ExternalURL string
+}
+
+type hangarPagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Count  int `json:"count"`
+}
+
+type hangarProjects struct {
+	Pagination hangarPagination `json:"pagination"`
+	Result     []struct {
+		Name      string `json:"name"`
+		Namespace struct {
+			Owner string `json:"owner"`
+			Slug  string `json:"slug"`
+		} `json:"namespace"`
+		Description string `json:"description"`
+		Category    string `json:"category"`
+		LastUpdated string `json:"lastUpdated"`
+		Stats       struct {
+			Downloads int64 `json:"downloads"`
+			Stars     int64 `json:"stars"`
+		} `json:"stats"`
+	} `json:"result"`
+}
+
+type hangarVersions struct {
+	Pagination hangarPagination `json:"pagination"`
+	Result     []hangarVersion  `json:"result"`
+}
+
+type hangarVersion struct {
+	Name        string `json:"name"`
+	CreatedAt   string `json:"createdAt"`
+	Description string `json:"description"`
+	Channel     struct {
+		Name string `json:"name"`
+	} `json:"channel"`
+	Downloads map[string]struct {
+		FileInfo struct {
+			Name       string `json:"name"`
+			SizeBytes  int64  `json:"sizeBytes"`
+			Sha256Hash string `json:"sha256Hash"`
+		} `json:"fileInfo"`
+		ExternalURL *string `json:"externalUrl"`
+		DownloadURL *string `json:"downloadUrl"`
+	} `json:"downloads"`
+	PlatformDependencies map[string][]string `json:"platformDependencies"`
+	PluginDependencies   map[string][]struct {
+		Name        string  `json:"name"`
+		Required    bool    `json:"required"`
+		ExternalURL *string `json:"externalUrl"`
+	} `json:"pluginDependencies"`
+}
+
+// Hangar is a client for the Hangar API.
+type Hangar struct {
+	server *url.URL
+}
+
+func NewHangar(server *url.URL) *Hangar {
+	return &Hangar{
+		server: server,
+	}
+}
+
+// SearchProjects returns up to limit projects matching query. platform
+// may be empty to search every platform.
+func (h *Hangar) SearchProjects(query string, platform string, limit int) ([]*HangarProject, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if platform != "" {
+		params.Set("platform", platform)
+	}
+
+	u := fmt.Sprintf("%s/api/v1/projects?%s", h.server.String(), params.Encode())
+
+	hp := &hangarProjects{}
+	_, err := fetch(u, hp)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := []*HangarProject{}
+	for _, r := range hp.Result {
+		p := &HangarProject{
+			Name:        r.Name,
+			Owner:       r.Namespace.Owner,
+			Slug:        r.Namespace.Slug,
+			Description: r.Description,
+			Category:    r.Category,
+			Downloads:   r.Stats.Downloads,
+			Stars:       r.Stats.Stars,
+		}
+		p.LastUpdated, err = ParseTime(r.LastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Namespace.Slug, err)
+		}
+		projects = append(projects, p)
+	}
+
+	return projects, nil
+}
+
+// ListVersions returns up to limit versions of the project slug, newest
+// first. platform and platformVersion narrow the list to versions that
+// declare support for them; either may be empty.
+func (h *Hangar) ListVersions(slug string, platform string, platformVersion string, limit int) ([]*HangarVersion, error) {
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	if platform != "" {
+		params.Set("platform", platform)
+	}
+	if platformVersion != "" {
+		params.Set("platformVersion", platformVersion)
+	}
+
+	u := fmt.Sprintf("%s/api/v1/projects/%s/versions?%s", h.server.String(), url.PathEscape(slug), params.Encode())
+
+	hv := &hangarVersions{}
+	_, err := fetch(u, hv)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []*HangarVersion{}
+	for i := range hv.Result {
+		v, err := hangarVersionToVersion(slug, &hv.Result[i])
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
+
+func hangarVersionToVersion(slug string, hv *hangarVersion) (*HangarVersion, error) {
+	v := &HangarVersion{
+		Slug:                 slug,
+		Name:                 hv.Name,
+		Channel:              hv.Channel.Name,
+		Description:          hv.Description,
+		Downloads:            map[string]*HangarDownload{},
+		PlatformDependencies: hv.PlatformDependencies,
+		PluginDependencies:   map[string][]*HangarDependency{},
+	}
+
+	var err error
+	v.CreatedAt, err = ParseTime(hv.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", slug, hv.Name, err)
+	}
+
+	for platform, d := range hv.Downloads {
+		v.Downloads[platform] = &HangarDownload{
+			FileName:    d.FileInfo.Name,
+			Size:        d.FileInfo.SizeBytes,
+			Sha256:      d.FileInfo.Sha256Hash,
+			URL:         stringOrEmpty(d.DownloadURL),
+			ExternalURL: stringOrEmpty(d.ExternalURL),
+		}
+	}
+
+	for platform, deps := range hv.PluginDependencies {
+		for _, d := range deps {
+			v.PluginDependencies[platform] = append(v.PluginDependencies[platform], &HangarDependency{
+				Name:        d.Name,
+				Required:    d.Required,
+				ExternalURL: stringOrEmpty(d.ExternalURL),
+			})
+		}
+	}
+
+	return v, nil
+}
+
+// stringOrEmpty is for the API's nullable strings.
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// Platforms returns the platforms the version can be downloaded for,
+// sorted.
+func (v *HangarVersion) Platforms() []string {
+	platforms := make([]string, 0, len(v.Downloads))
+	for platform := range v.Downloads {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	return platforms
+}
+
+// Artifact returns the version's jar for platform in the shape
+// DownloadPlugin takes.
+func (v *HangarVersion) Artifact(platform string) (*Artifact, error) {
+	d, ok := v.Downloads[platform]
+	if !ok {
+		return nil, fmt.Errorf("%s %s: no download for %s (available: %s)", v.Slug, v.Name, platform, strings.Join(v.Platforms(), ", "))
+	}
+
+	if d.URL == "" {
+		if d.ExternalURL != "" {
+			return nil, fmt.Errorf("%s %s: only available externally, from %s", v.Slug, v.Name, d.ExternalURL)
+		}
+		return nil, fmt.Errorf("%s %s: no download url", v.Slug, v.Name)
+	}
+
+	err := CheckFileName(d.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %v", v.Slug, v.Name, err)
+	}
+
+	name := d.FileName
+	src := d.URL
+	app := &Application{
+		Name: &name,
+		URL:  &src,
+	}
+	if d.Sha256 != "" {
+		sha := d.Sha256
+		app.Sha256 = &sha
+		app.Checksums = Checksums{"sha256": sha}
+	}
+	if d.Size > 0 {
+		size := d.Size
+		app.Size = &size
+	}
+
+	return &Artifact{Application: app}, nil
+}
+
+// Download fetches the version's jar for platform into dir, usually a
+// server's plugins/ directory, verifying it against the sha256 Hangar
+// publishes.
+func (h *Hangar) Download(v *HangarVersion, platform string, dir string, opts *DownloadOptions) error {
+	artifact, err := v.Artifact(platform)
+	if err != nil {
+		return err
+	}
+
+	return DownloadPlugin(artifact, dir, opts)
+}
@@ -0,0 +1,153 @@
+package papertool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// fakeHangar serves a search result and the versions of one project: a
+// Paper version hosted on Hangar and an older one only hosted elsewhere.
+func fakeHangar(t *testing.T, jar []byte) *url.URL {
+	sum := sha256.Sum256(jar)
+	sha := hex.EncodeToString(sum[:])
+
+	empty := serveJSON(`{"pagination":{"limit":25,"offset":0,"count":0},"result":[]}`)
+	search := serveJSON(`{"pagination":{"limit":25,"offset":0,"count":1},"result":[` +
+		`{"name":"ViaVersion","namespace":{"owner":"ViaVersion","slug":"ViaVersion"},"description":"Protocol support",` +
+		`"category":"protocol","lastUpdated":"2025-01-10T12:00:00.000Z","stats":{"downloads":123456,"stars":321}}]}`)
+	versions := serveJSON(`{"pagination":{"limit":25,"offset":0,"count":2},"result":[` +
+		`{"name":"5.2.1","createdAt":"2025-01-10T12:00:00.000Z","description":"changelog","channel":{"name":"Release"},` +
+		`"downloads":{"PAPER":{"fileInfo":{"name":"ViaVersion-5.2.1.jar","sizeBytes":` + strconv.Itoa(len(jar)) + `,"sha256Hash":"` + sha + `"},` +
+		`"externalUrl":null,"downloadUrl":"$SERVER/cdn/ViaVersion-5.2.1.jar"}},` +
+		`"platformDependencies":{"PAPER":["1.8-1.21.4"]},` +
+		`"pluginDependencies":{"PAPER":[{"name":"ViaBackwards","required":false,"platform":"PAPER"}]}},` +
+		`{"name":"5.2.0","createdAt":"2025-01-01T12:00:00.000Z","description":"","channel":{"name":"Release"},` +
+		`"downloads":{"PAPER":{"fileInfo":{"name":"ViaVersion-5.2.0.jar","sizeBytes":1,"sha256Hash":""},` +
+		`"externalUrl":"https://example.com/via.jar","downloadUrl":null}},"platformDependencies":{},"pluginDependencies":{}}]}`)
+
+	return fakeServer(t, map[string]http.HandlerFunc{
+		"/api/v1/projects": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("q") != "via" || r.URL.Query().Get("platform") != HangarPaper {
+				empty(w, r)
+				return
+			}
+			search(w, r)
+		},
+		"/api/v1/projects/ViaVersion/versions": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("platformVersion") == "1.8.8" {
+				empty(w, r)
+				return
+			}
+			versions(w, r)
+		},
+		"/api/v1/projects/missing/versions": serveStatus(http.StatusNotFound, `{"message":"Not found"}`),
+		"/cdn/ViaVersion-5.2.1.jar":         serveJar(jar),
+	})
+}
+
+func TestHangar(t *testing.T) {
+	jar := []byte("not really a plugin")
+	h := NewHangar(fakeHangar(t, jar))
+
+	projects, err := h.SearchProjects("via", HangarPaper, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || projects[0].Slug != "ViaVersion" || projects[0].Downloads != 123456 {
+		t.Fatalf("search: got %+v", projects)
+	}
+	if projects[0].LastUpdated.Year() != 2025 {
+		t.Fatalf("search: last updated %v", projects[0].LastUpdated)
+	}
+
+	versions, err := h.ListVersions("ViaVersion", HangarPaper, "1.21.4", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].Name != "5.2.1" || versions[0].Channel != "Release" {
+		t.Fatalf("versions: got %+v", versions)
+	}
+	deps := versions[0].PluginDependencies[HangarPaper]
+	if len(deps) != 1 || deps[0].Name != "ViaBackwards" || deps[0].Required {
+		t.Fatalf("dependencies: got %+v", deps)
+	}
+
+	none, err := h.ListVersions("ViaVersion", HangarPaper, "1.8.8", 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(none) != 0 {
+		t.Fatalf("1.8.8: got %d versions", len(none))
+	}
+
+	_, err = h.ListVersions("missing", HangarPaper, "", 25)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing: got %v", err)
+	}
+
+	_, err = versions[1].Artifact(HangarPaper)
+	if err == nil {
+		t.Fatalf("external: expected error")
+	}
+	_, err = versions[0].Artifact(HangarVelocity)
+	if err == nil {
+		t.Fatalf("velocity: expected error")
+	}
+
+	// Plugin jars aren't server builds, so they mustn't be pinned or
+	// recorded as installs.
+	db, dbPath, _ := testChecksumDB(t, PinStrict)
+	saved := KnownChecksums
+	KnownChecksums = db
+	t.Cleanup(func() { KnownChecksums = saved })
+
+	dir := t.TempDir()
+	opts := DefaultDownloadOptions()
+	opts.Progress = NopProgress{}
+
+	err = h.Download(versions[0], HangarPaper, dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "ViaVersion-5.2.1.jar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(jar) {
+		t.Fatalf("downloaded: got %q", got)
+	}
+
+	_, err = os.Stat(dbPath)
+	if !os.IsNotExist(err) {
+		t.Fatalf("checksum db: expected nothing pinned, got %v", err)
+	}
+	_, err = os.Stat(HistoryPath(dir))
+	if !os.IsNotExist(err) {
+		t.Fatalf("history: expected none, got %v", err)
+	}
+
+	versions[0].Downloads[HangarPaper].Sha256 = hex.EncodeToString(make([]byte, sha256.Size))
+	opts.Replace = true
+	err = h.Download(versions[0], HangarPaper, dir, opts)
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Algorithm != "sha256" {
+		t.Fatalf("bad sha256: got %v", err)
+	}
+
+	// A file name from the server mustn't escape the plugins directory.
+	for _, name := range []string{"../../evil.jar", "/tmp/evil.jar", "..", ""} {
+		versions[0].Downloads[HangarPaper].FileName = name
+		_, err = versions[0].Artifact(HangarPaper)
+		if err == nil {
+			t.Fatalf("file name %q: expected error", name)
+		}
+	}
+}
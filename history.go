@@ -0,0 +1,158 @@
+package papertool
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+ * Every build downloaded into a directory (DownloadOptions.History; the
+ * download, init and run commands set it, -o doesn't) is recorded in
+ * .papertool/history.jsonl in that directory, one JSON object per line,
+ * oldest first:
+ *
+ *   {"time":"2026-10-18T04:15:00Z","project":"paper","version":"1.21.4","build":"232",
+ *    "artifact":"paper-1.21.4-232.jar","sha256":"...","url":"https://fill-data.papermc.io/..."}
+ */
+
+const HistoryName = "history.jsonl"
+
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`
+	Project  string    `json:"project"`
+	Version  string    `json:"version"`
+	Build    string    `json:"build"`
+	Artifact string    `json:"artifact"`
+	Sha256   string    `json:"sha256"`
+	URL      string    `json:"url"`
+}
+
+// historyMu serialises appends from concurrent downloads, e.g.
+// DownloadBatch.
+var historyMu sync.Mutex
+
+func HistoryPath(dir string) string {
+	return filepath.Join(dir, InstallDirName, HistoryName)
+}
+
+// AppendHistory adds e to dir's history.
+func AppendHistory(dir string, e *HistoryEntry) error {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	path := HistoryPath(dir)
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(raw, '\n'))
+	cerr := f.Close()
+	if err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// ReadHistory returns dir's history, oldest first. A directory with no
+// history has an empty one.
+func ReadHistory(dir string) ([]*HistoryEntry, error) {
+	path := HistoryPath(dir)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []*HistoryEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []*HistoryEntry{}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		e := &HistoryEntry{}
+		err = json.Unmarshal(scanner.Bytes(), e)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return entries, nil
+}
+
+// ChangesBetween returns the builds of prev's project and version after
+// prev and up to and including cur, oldest first, with their changes. It
+// returns nil if the two entries aren't of the same project and version
+// or their builds aren't numbers.
+func ChangesBetween(p Provider, prev *HistoryEntry, cur *HistoryEntry) ([]*Build, error) {
+	if prev.Project != cur.Project || prev.Version != cur.Version {
+		return nil, nil
+	}
+
+	from, err := strconv.Atoi(prev.Build)
+	if err != nil {
+		return nil, nil
+	}
+	to, err := strconv.Atoi(cur.Build)
+	if err != nil {
+		return nil, nil
+	}
+	if from >= to {
+		return nil, nil
+	}
+
+	builds, err := p.ListBuilds(cur.Project, cur.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	between := []*Build{}
+	for _, b := range builds.Builds {
+		if b.Build == nil || int(*b.Build) <= from || int(*b.Build) > to {
+			continue
+		}
+
+		// Not every provider's listing includes the changes.
+		if b.Artifact == nil {
+			b, err = p.GetBuild(cur.Project, cur.Version, String(b.Build))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		between = append(between, b)
+	}
+
+	return between, nil
+}
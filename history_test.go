@@ -0,0 +1,232 @@
+package papertool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	entries, err := ReadHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("1: expected no history, got %d", len(entries))
+	}
+
+	first := &HistoryEntry{
+		Time:     time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC),
+		Project:  "paper",
+		Version:  "1.21.4",
+		Build:    "232",
+		Artifact: "paper-1.21.4-232.jar",
+		Sha256:   "aaaa",
+		URL:      "https://example.com/paper-1.21.4-232.jar",
+	}
+	err = AppendHistory(dir, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent appends, as from DownloadBatch, mustn't interleave.
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := AppendHistory(dir, &HistoryEntry{Project: "paper", Version: "1.21.4", Build: fmt.Sprint(300 + i), URL: strings.Repeat("x", 4096)})
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries, err = ReadHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 21 {
+		t.Fatalf("2: expected 21 entries, got %d", len(entries))
+	}
+	if *entries[0] != *first {
+		t.Fatalf("3: expected %+v got %+v", first, entries[0])
+	}
+
+	// Blank lines are skipped, anything else bad is an error.
+	f, err := os.OpenFile(HistoryPath(dir), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteString("\n")
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err = ReadHistory(dir)
+	if err != nil || len(entries) != 21 {
+		t.Fatalf("4: got %d entries, %v", len(entries), err)
+	}
+
+	err = os.WriteFile(HistoryPath(dir), []byte("{\"project\":\"paper\"}\nnot json\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadHistory(dir)
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Fatalf("5: expected an error for line 2, got %v", err)
+	}
+}
+
+func TestChangesBetween(t *testing.T) {
+	p := NewPurpurProvider(fakePurpur(t, []byte("not really a jar")))
+
+	entry := func(version string, build string) *HistoryEntry {
+		return &HistoryEntry{Project: Project_Purpur, Version: version, Build: build}
+	}
+
+	tests := []struct {
+		prev *HistoryEntry
+		cur  *HistoryEntry
+		want []string
+	}{
+		{entry("1.21.4", "2414"), entry("1.21.4", "2416"), []string{"2415", "2416"}},
+		{entry("1.21.4", "2414"), entry("1.21.4", "2415"), []string{"2415"}},
+		{entry("1.21.4", "2415"), entry("1.21.4", "2415"), nil},
+		{entry("1.21.4", "2416"), entry("1.21.4", "2415"), nil},
+		{entry("1.21.3", "2300"), entry("1.21.4", "2415"), nil},
+		{entry("1.21.4", "latest"), entry("1.21.4", "2415"), nil},
+	}
+
+	for _, test := range tests {
+		builds, err := ChangesBetween(p, test.prev, test.cur)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []string(nil)
+		for _, b := range builds {
+			got = append(got, String(b.Build))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Fatalf("%s %s to %s %s: expected %v got %v", test.prev.Version, test.prev.Build, test.cur.Version, test.cur.Build, test.want, got)
+		}
+	}
+
+	// The listing leaves the changes out, so they're fetched.
+	builds, err := ChangesBetween(p, entry("1.21.4", "2414"), entry("1.21.4", "2415"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds[0].Changes) != 1 || String(builds[0].Changes[0].Commit) != "abc123" {
+		t.Fatalf("changes: got %+v", builds[0].Changes)
+	}
+}
+
+func TestDownloadHistory(t *testing.T) {
+	server := fakePurpur(t, []byte("not really a jar"))
+	p := NewPurpurProvider(server)
+
+	b, err := p.GetBuild(Project_Purpur, "1.21.4", "2415")
+	if err != nil {
+		t.Fatal(err)
+	}
+	artifact, err := ResolveArtifact(p, Project_Purpur, "1.21.4", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	download := func(dir string, history bool, output string) []string {
+		warnings := []string{}
+		opts := DefaultDownloadOptions()
+		opts.Progress = NopProgress{}
+		opts.History = history
+		opts.Output = output
+		opts.Warnf = func(format string, args ...interface{}) {
+			warnings = append(warnings, fmt.Sprintf(format, args...))
+		}
+
+		err := DownloadOpts(server, Project_Purpur, "1.21.4", "2415", artifact, dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return warnings
+	}
+
+	historyLen := func(dir string) int {
+		entries, err := ReadHistory(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+
+	// Recorded when asked for.
+	dir := t.TempDir()
+	download(dir, true, "")
+	entries, err := ReadHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Build != "2415" || entries[0].Artifact != "purpur-1.21.4-2415.jar" || entries[0].Sha256 == "" {
+		t.Fatalf("1: got %+v", entries)
+	}
+
+	// Download always records, the first install included.
+	dir = t.TempDir()
+	err = Download(server, Project_Purpur, "1.21.4", "2415", artifact, dir, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if historyLen(dir) != 1 {
+		t.Fatalf("7: Download didn't record history")
+	}
+
+	// Not when it isn't, nor for an -o path.
+	dir = t.TempDir()
+	download(dir, false, "")
+	if historyLen(dir) != 0 {
+		t.Fatalf("2: recorded without History")
+	}
+	dir = t.TempDir()
+	download(dir, true, filepath.Join(t.TempDir(), "server.jar"))
+	if historyLen(dir) != 0 {
+		t.Fatalf("3: recorded for Output")
+	}
+
+	// A history that can't be written is only a warning.
+	dir = t.TempDir()
+	err = os.WriteFile(filepath.Join(dir, InstallDirName), []byte("in the way"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warnings := download(dir, true, "")
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "recording history") {
+		t.Fatalf("4: expected a warning, got %q", warnings)
+	}
+	_, err = os.Stat(filepath.Join(dir, "purpur-1.21.4-2415.jar"))
+	if err != nil {
+		t.Fatalf("5: download lost: %v", err)
+	}
+
+	// DownloadBatch records history the same way.
+	dir = t.TempDir()
+	opts := &BatchOptions{DstDir: dir, Workers: 2}
+	opts.Progress = NopProgress{}
+	opts.History = true
+	err = DownloadBatch(server, []*DownloadTarget{{Project: Project_Purpur, Version: "1.21.4", Build: "2415", Artifact: artifact}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if historyLen(dir) != 1 {
+		t.Fatalf("6: batch didn't record history")
+	}
+}
@@ -0,0 +1,314 @@
+package papertool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/*
+ * A server directory set up by papertool looks like:
+ *
+ *   paper-1.21.4-232.jar
+ *   eula.txt                  (if the EULA was accepted)
+ *   start.sh
+ *   .papertool/install.json   (the InstallRecord)
+ */
+
+const (
+	InstallDirName  = ".papertool"
+	StartScriptName = "start.sh"
+	EULAName        = "eula.txt"
+)
+
+// DefaultJVMFlags are Aikar's flags, which PaperMC recommends for servers
+// and which the Fill API publishes per version. Used when a provider
+// doesn't publish its own.
+var DefaultJVMFlags = []string{
+	"-XX:+AlwaysPreTouch",
+	"-XX:+DisableExplicitGC",
+	"-XX:+ParallelRefProcEnabled",
+	"-XX:+PerfDisableSharedMem",
+	"-XX:+UnlockExperimentalVMOptions",
+	"-XX:+UseG1GC",
+	"-XX:G1HeapRegionSize=8M",
+	"-XX:G1HeapWastePercent=5",
+	"-XX:G1MaxNewSizePercent=40",
+	"-XX:G1MixedGCCountTarget=4",
+	"-XX:G1MixedGCLiveThresholdPercent=90",
+	"-XX:G1NewSizePercent=30",
+	"-XX:G1RSetUpdatingPauseTimePercent=5",
+	"-XX:G1ReservePercent=20",
+	"-XX:InitiatingHeapOccupancyPercent=15",
+	"-XX:MaxGCPauseMillis=200",
+	"-XX:MaxTenuringThreshold=1",
+	"-XX:SurvivorRatio=32",
+}
+
+// InstallRecord says what papertool installed in a server directory.
+type InstallRecord struct {
+	Provider    string    `json:"provider"`
+	Server      string    `json:"server"`
+	Project     string    `json:"project"`
+	Version     string    `json:"version"`
+	Build       string    `json:"build"`
+	Channel     string    `json:"channel,omitempty"` // "" for any
+	Jar         string    `json:"jar"`               // relative to the server directory
+	Checksums   Checksums `json:"checksums,omitempty"`
+	Java        string    `json:"java,omitempty"`
+	Memory      string    `json:"memory,omitempty"`
+	JVMFlags    []string  `json:"jvm_flags,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+func InstallRecordPath(dir string) string {
+	return filepath.Join(dir, InstallDirName, "install.json")
+}
+
+// ReadInstallRecord reads dir's install record. The error satisfies
+// os.IsNotExist if the directory wasn't set up by papertool.
+func ReadInstallRecord(dir string) (*InstallRecord, error) {
+	raw, err := os.ReadFile(InstallRecordPath(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &InstallRecord{}
+	err = json.Unmarshal(raw, rec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", InstallRecordPath(dir), err)
+	}
+
+	return rec, nil
+}
+
+func WriteInstallRecord(dir string, rec *InstallRecord) error {
+	raw, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := InstallRecordPath(dir)
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, append(raw, '\n'), 0644)
+}
+
+// writeFileAtomic writes via a temporary file so a crash can't leave path
+// truncated.
+func writeFileAtomic(path string, raw []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	err := os.WriteFile(tmp, raw, perm)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// AcceptEULA writes an eula.txt agreeing to the Minecraft EULA, as the
+// server would after the operator edits the one it generates. An existing
+// eula.txt is kept: if it already agrees it's left alone, otherwise only
+// its eula= line is changed.
+func AcceptEULA(dir string, now time.Time) error {
+	path := filepath.Join(dir, EULAName)
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		text := "#By changing the setting below to TRUE you are indicating your agreement to our EULA (https://aka.ms/MinecraftEULA).\n" +
+			"#" + now.Format("Mon Jan 02 15:04:05 MST 2006") + "\n" +
+			"eula=true\n"
+
+		return writeFileAtomic(path, []byte(text), 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(raw), "\n"), "\n")
+	found := false
+	for i, line := range lines {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || strings.TrimSpace(key) != "eula" {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(value), "true") {
+			return nil
+		}
+		lines[i] = "eula=true"
+		found = true
+	}
+	if !found {
+		lines = append(lines, "eula=true")
+	}
+
+	return writeFileAtomic(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+var memoryRE = regexp.MustCompile(`^[1-9][0-9]*[MmGg]$`)
+
+// StartScript describes the start.sh written into a server directory.
+type StartScript struct {
+	Java     string   // java binary, "java" if empty
+	Memory   string   // heap size for -Xms and -Xmx, e.g. 4G
+	JVMFlags []string // typically Version.JavaFlags or DefaultJVMFlags
+	Jar      string   // relative to the server directory
+}
+
+// JVMArgs returns the arguments to run the server with, up to and
+// including the jar and --nogui.
+func (s *StartScript) JVMArgs() []string {
+	args := []string{}
+	if s.Memory != "" {
+		args = append(args, "-Xms"+s.Memory, "-Xmx"+s.Memory)
+	}
+	args = append(args, s.JVMFlags...)
+	args = append(args, "-jar", s.Jar, "--nogui")
+
+	return args
+}
+
+// CheckMemory checks a heap size is something the JVM's -Xmx takes.
+func CheckMemory(memory string) error {
+	if !memoryRE.MatchString(memory) {
+		return fmt.Errorf("bad memory size '%s', want a number of megabytes or gigabytes like 512M or 4G", memory)
+	}
+
+	return nil
+}
+
+// Write writes the script into dir as start.sh.
+func (s *StartScript) Write(dir string) error {
+	if s.Memory != "" {
+		err := CheckMemory(s.Memory)
+		if err != nil {
+			return err
+		}
+	}
+
+	java := s.Java
+	if java == "" {
+		java = "java"
+	}
+
+	quoted := []string{shellQuote(java)}
+	for _, arg := range s.JVMArgs() {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	script := "#!/bin/sh\n" +
+		"# Written by papertool init.\n" +
+		"cd \"$(dirname \"$0\")\" || exit 1\n" +
+		"exec " + strings.Join(quoted, " ") + " \"$@\"\n"
+
+	return writeFileAtomic(filepath.Join(dir, StartScriptName), []byte(script), 0755)
+}
+
+// shellQuote quotes s for sh if it needs it.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=+:,./@%", r))
+	}) < 0 {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Command returns the command line the record's server runs with.
+func (rec *InstallRecord) Command() []string {
+	java := rec.Java
+	if java == "" {
+		java = "java"
+	}
+
+	script := &StartScript{
+		Memory:   rec.Memory,
+		JVMFlags: rec.JVMFlags,
+		Jar:      rec.Jar,
+	}
+
+	return append([]string{java}, script.JVMArgs()...)
+}
+
+// UpdateInstall installs the newest build in channel of the version
+// recorded in dir, using p. If channel is "" the channel recorded at
+// install time is used. The jar is replaced, start.sh and the
+// install record are rewritten, and the old jar is removed. It reports
+// whether anything changed.
+func UpdateInstall(p Provider, dir string, channel string, opts *DownloadOptions) (*InstallRecord, bool, error) {
+	rec, err := ReadInstallRecord(dir)
+	if err != nil {
+		return nil, false, err
+	}
+
+	builds, err := p.ListBuilds(rec.Project, rec.Version)
+	if err != nil {
+		return rec, false, err
+	}
+
+	if channel == "" {
+		channel = rec.Channel
+	}
+
+	latest, err := LatestBuild(p, rec.Project, rec.Version, builds.InChannel(channel))
+	if err != nil {
+		return rec, false, err
+	}
+
+	build := String(latest.Build)
+	if build == rec.Build {
+		return rec, false, nil
+	}
+
+	artifact, err := ResolveArtifact(p, rec.Project, rec.Version, latest)
+	if err != nil {
+		return rec, false, err
+	}
+
+	dopts := *opts
+	dopts.History = true
+
+	err = DownloadOpts(nil, rec.Project, rec.Version, build, artifact, dir, &dopts)
+	if err != nil {
+		return rec, false, err
+	}
+
+	old := rec.Jar
+	updated := *rec
+	updated.Build = build
+	updated.Jar = String(artifact.Application.Name)
+	updated.Checksums = artifact.Application.ExpectedChecksums().Known()
+	updated.InstalledAt = time.Now().UTC()
+
+	script := &StartScript{
+		Java:     updated.Java,
+		Memory:   updated.Memory,
+		JVMFlags: updated.JVMFlags,
+		Jar:      updated.Jar,
+	}
+
+	err = script.Write(dir)
+	if err != nil {
+		return rec, false, err
+	}
+
+	err = WriteInstallRecord(dir, &updated)
+	if err != nil {
+		return rec, false, err
+	}
+
+	if old != "" && old != updated.Jar {
+		os.Remove(filepath.Join(dir, old))
+	}
+
+	return &updated, true, nil
+}
@@ -0,0 +1,226 @@
+package papertool
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"java", "java"},
+		{"-XX:G1HeapRegionSize=8M", "-XX:G1HeapRegionSize=8M"},
+		{"/opt/jdk-21/bin/java", "/opt/jdk-21/bin/java"},
+		{"", "''"},
+		{"/opt/My Java/bin/java", "'/opt/My Java/bin/java'"},
+		{"it's", `'it'\''s'`},
+		{`-Dmotd="hi there"`, `'-Dmotd="hi there"'`},
+		{"$HOME", "'$HOME'"},
+		{"a;rm -rf /", "'a;rm -rf /'"},
+	}
+
+	for _, test := range tests {
+		got := shellQuote(test.in)
+		if got != test.want {
+			t.Fatalf("%q: expected %s got %s", test.in, test.want, got)
+		}
+	}
+}
+
+func TestStartScript(t *testing.T) {
+	tests := []struct {
+		script *StartScript
+		args   []string
+		exec   string
+	}{
+		{
+			&StartScript{Jar: "paper-1.21.4-232.jar"},
+			[]string{"-jar", "paper-1.21.4-232.jar", "--nogui"},
+			`exec java -jar paper-1.21.4-232.jar --nogui "$@"`,
+		},
+		{
+			&StartScript{Java: "/opt/My Java/bin/java", Memory: "4G", JVMFlags: []string{"-XX:+UseG1GC", "-Dname=it's"}, Jar: "paper.jar"},
+			[]string{"-Xms4G", "-Xmx4G", "-XX:+UseG1GC", "-Dname=it's", "-jar", "paper.jar", "--nogui"},
+			`exec '/opt/My Java/bin/java' -Xms4G -Xmx4G -XX:+UseG1GC '-Dname=it'\''s' -jar paper.jar --nogui "$@"`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.script.JVMArgs(); !reflect.DeepEqual(got, test.args) {
+			t.Fatalf("%s: expected args %q got %q", test.script.Jar, test.args, got)
+		}
+
+		dir := t.TempDir()
+		err := test.script.Write(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(dir, StartScriptName)
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(raw), "#!/bin/sh\n") || !strings.Contains(string(raw), test.exec+"\n") {
+			t.Fatalf("%s: bad script:\n%s", test.script.Jar, raw)
+		}
+
+		st, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Mode().Perm()&0111 == 0 {
+			t.Fatalf("%s: not executable: %v", test.script.Jar, st.Mode())
+		}
+	}
+
+	for _, memory := range []string{"4", "0G", "4GB", "-4G", "4 G"} {
+		err := (&StartScript{Memory: memory, Jar: "paper.jar"}).Write(t.TempDir())
+		if err == nil {
+			t.Fatalf("%q: expected error", memory)
+		}
+	}
+}
+
+func TestAcceptEULA(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		existing string // "" for no eula.txt
+		want     string // "" to check the generated file
+	}{
+		{"new", "", ""},
+		{"declined", "#keep this comment\neula=false\n", "#keep this comment\neula=true\n"},
+		{"no setting", "#just a comment\n", "#just a comment\neula=true\n"},
+		{"already accepted", "#operator's own file\neula=TRUE\n", "#operator's own file\neula=TRUE\n"},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+		path := filepath.Join(dir, EULAName)
+		if test.existing != "" {
+			err := os.WriteFile(path, []byte(test.existing), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err := AcceptEULA(dir, now)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.want == "" {
+			if !strings.HasSuffix(string(raw), "\neula=true\n") || !strings.Contains(string(raw), "#Sun May 10 12:00:00 UTC 2026\n") {
+				t.Fatalf("%s: bad eula.txt:\n%s", test.name, raw)
+			}
+			continue
+		}
+		if string(raw) != test.want {
+			t.Fatalf("%s: expected %q got %q", test.name, test.want, raw)
+		}
+	}
+}
+
+func TestUpdateInstall(t *testing.T) {
+	p := NewPurpurProvider(fakePurpur(t, []byte("not really a jar")))
+	dir := t.TempDir()
+
+	old := "purpur-1.21.4-2400.jar"
+	err := os.WriteFile(filepath.Join(dir, old), []byte("old jar"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &InstallRecord{
+		Provider: Project_Purpur,
+		Project:  Project_Purpur,
+		Version:  "1.21.4",
+		Build:    "2400",
+		Jar:      old,
+		Memory:   "2G",
+	}
+	err = WriteInstallRecord(dir, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultDownloadOptions()
+	opts.Progress = NopProgress{}
+
+	// 2416 failed, so 2415 is the one to install.
+	updated, changed, err := UpdateInstall(p, dir, "", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || updated.Build != "2415" || updated.Jar != "purpur-1.21.4-2415.jar" || updated.Memory != "2G" {
+		t.Fatalf("1: got changed %v %+v", changed, updated)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, old))
+	if !os.IsNotExist(err) {
+		t.Fatalf("2: old jar not removed: %v", err)
+	}
+
+	onDisk, err := ReadInstallRecord(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if onDisk.Build != "2415" || onDisk.Checksums["md5"] == "" {
+		t.Fatalf("3: record not updated: %+v", onDisk)
+	}
+
+	script, err := os.ReadFile(filepath.Join(dir, StartScriptName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(script), "-Xmx2G -jar purpur-1.21.4-2415.jar") {
+		t.Fatalf("4: start.sh not updated:\n%s", script)
+	}
+
+	_, changed, err = UpdateInstall(p, dir, "", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Fatalf("5: expected no change")
+	}
+
+	_, _, err = UpdateInstall(p, t.TempDir(), "", opts)
+	if !os.IsNotExist(err) {
+		t.Fatalf("6: expected not exist, got %v", err)
+	}
+	// The channel recorded at install time is used unless another is
+	// asked for. Purpur only has STABLE builds.
+	onDisk.Build = "2400"
+	onDisk.Channel = "EXPERIMENTAL"
+	err = WriteInstallRecord(dir, onDisk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, changed, err = UpdateInstall(p, dir, "", opts)
+	if err == nil || changed {
+		t.Fatalf("7: expected no EXPERIMENTAL builds, got changed %v", changed)
+	}
+
+	updated, changed, err = UpdateInstall(p, dir, "STABLE", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || updated.Build != "2415" || updated.Channel != "EXPERIMENTAL" {
+		t.Fatalf("8: got changed %v %+v", changed, updated)
+	}
+}
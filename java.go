@@ -0,0 +1,106 @@
+package papertool
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// JavaRuntime describes a local Java installation.
+type JavaRuntime struct {
+	Path    string // the java binary
+	Version string // as reported, e.g. "21.0.2" or "1.8.0_402"
+	Feature int    // the feature release, e.g. 21 or 8
+}
+
+// JavaTooOldError is returned by CheckJava when the runtime is older than
+// the version requires.
+type JavaTooOldError struct {
+	Runtime *JavaRuntime
+	Project string
+	Version string
+	Minimum int
+}
+
+func (e *JavaTooOldError) Error() string {
+	return fmt.Sprintf("%s %s requires Java %d or newer, but %s is Java %d (%s)", e.Project, e.Version, e.Minimum, e.Runtime.Path, e.Runtime.Feature, e.Runtime.Version)
+}
+
+var javaVersionRE = regexp.MustCompile(`version "([^"]+)"`)
+
+// DetectJava runs `java -version` and parses the result. If javaHome is
+// set, $javaHome/bin/java is used, otherwise java is looked up on $PATH.
+func DetectJava(javaHome string) (*JavaRuntime, error) {
+	bin := "java"
+	if javaHome != "" {
+		bin = filepath.Join(javaHome, "bin", "java")
+	}
+
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return nil, fmt.Errorf("find java: %v", err)
+	}
+
+	// -version writes to stderr.
+	out, err := exec.Command(path, "-version").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s -version: %v", path, err)
+	}
+
+	version, feature, err := ParseJavaVersion(string(out))
+	if err != nil {
+		return nil, fmt.Errorf("%s -version: %v", path, err)
+	}
+
+	return &JavaRuntime{
+		Path:    path,
+		Version: version,
+		Feature: feature,
+	}, nil
+}
+
+// ParseJavaVersion pulls the version string out of `java -version` output
+// and works out the feature release from it. Pre-9 runtimes report
+// themselves as 1.x, so "1.8.0_402" is feature release 8.
+func ParseJavaVersion(output string) (string, int, error) {
+	m := javaVersionRE.FindStringSubmatch(output)
+	if m == nil {
+		return "", 0, fmt.Errorf("no version in output %q", strings.TrimSpace(output))
+	}
+	version := m[1]
+
+	parts := strings.FieldsFunc(version, func(r rune) bool {
+		return r == '.' || r == '_' || r == '-' || r == '+'
+	})
+	if len(parts) > 1 && parts[0] == "1" {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 {
+		return "", 0, fmt.Errorf("bad version %q", version)
+	}
+
+	feature, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", 0, fmt.Errorf("bad version %q", version)
+	}
+
+	return version, feature, nil
+}
+
+// CheckJava returns a *JavaTooOldError if rt can't run v. A version that
+// doesn't publish a Java requirement always passes.
+func CheckJava(rt *JavaRuntime, v *Version) error {
+	if v.JavaMinimum <= 0 || rt.Feature >= v.JavaMinimum {
+		return nil
+	}
+
+	return &JavaTooOldError{
+		Runtime: rt,
+		Project: v.ProjectID,
+		Version: v.ID,
+		Minimum: v.JavaMinimum,
+	}
+}
@@ -0,0 +1,66 @@
+package papertool
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseJavaVersion(t *testing.T) {
+	tests := []struct {
+		output  string
+		version string
+		feature int
+	}{
+		{`java version "1.8.0_392"
+Java(TM) SE Runtime Environment (build 1.8.0_392-b08)
+Java HotSpot(TM) 64-Bit Server VM (build 25.392-b08, mixed mode)`, "1.8.0_392", 8},
+		{`openjdk version "21.0.2" 2024-01-16 LTS
+OpenJDK Runtime Environment Temurin-21.0.2+13 (build 21.0.2+13-LTS)
+OpenJDK 64-Bit Server VM Temurin-21.0.2+13 (build 21.0.2+13-LTS, mixed mode, sharing)`, "21.0.2", 21},
+		{`openjdk version "17.0.10" 2024-01-16
+OpenJDK Runtime Environment Corretto-17.0.10.7.1 (build 17.0.10+7-LTS)`, "17.0.10", 17},
+		{`openjdk version "22-ea" 2024-03-19
+OpenJDK Runtime Environment (build 22-ea+27-2262)`, "22-ea", 22},
+		{`openjdk version "11.0.22" 2024-01-16
+OpenJDK Runtime Environment GraalVM CE 22.3.5 (build 11.0.22+7-jvmci-22.3-b33)`, "11.0.22", 11},
+		{`openjdk version "25" 2025-09-16`, "25", 25},
+	}
+
+	for _, test := range tests {
+		version, feature, err := ParseJavaVersion(test.output)
+		if err != nil {
+			t.Fatalf("%s: %v", test.version, err)
+		}
+		if version != test.version || feature != test.feature {
+			t.Fatalf("%s: got %q %d", test.version, version, feature)
+		}
+	}
+
+	for _, bad := range []string{"", "command not found", `java version "abc"`} {
+		_, _, err := ParseJavaVersion(bad)
+		if err == nil {
+			t.Fatalf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestCheckJava(t *testing.T) {
+	v := &Version{ProjectID: "paper", ID: "1.21.4", JavaMinimum: 21}
+
+	err := CheckJava(&JavaRuntime{Path: "java", Version: "21.0.2", Feature: 21}, v)
+	if err != nil {
+		t.Fatalf("1: %v", err)
+	}
+
+	err = CheckJava(&JavaRuntime{Path: "java", Version: "1.8.0_392", Feature: 8}, v)
+	var tooOld *JavaTooOldError
+	if !errors.As(err, &tooOld) || tooOld.Minimum != 21 {
+		t.Fatalf("2: expected JavaTooOldError got %v", err)
+	}
+
+	v.JavaMinimum = 0
+	err = CheckJava(&JavaRuntime{Path: "java", Version: "1.8.0_392", Feature: 8}, v)
+	if err != nil {
+		t.Fatalf("3: %v", err)
+	}
+}
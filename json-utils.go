@@ -1,6 +1,7 @@
 package papertool
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -9,6 +10,9 @@ import (
 //
 // From https://gist.github.com/alexmcroberts/219127816e7a16c7bd70
 //
+// Accepts either epoch milliseconds or an RFC3339 string, since the v3
+// API sends the latter.
+//
 
 type JsonTime time.Time
 
@@ -21,12 +25,66 @@ func (t *JsonTime) UnmarshalJSON(s []byte) (err error) {
 
 	q, err := strconv.ParseInt(r, 10, 64)
 	if err != nil {
-		return err
+		pt, perr := ParseTime(r)
+		if perr != nil {
+			return err
+		}
+		*(*time.Time)(t) = pt
+		return nil
 	}
-	*(*time.Time)(t) = time.Unix(q/1000, 0)
+	*(*time.Time)(t) = time.UnixMilli(q)
 	return
 }
 
 func (t JsonTime) String() string {
 	return time.Time(t).String()
 }
+
+// ParseTime parses an RFC3339 timestamp as sent by the v3 API. An empty
+// string is the zero time.
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse time %q: %v", s, err)
+	}
+
+	return t, nil
+}
+
+// RelativeTime describes t relative to now, e.g. "3 days ago".
+func RelativeTime(t time.Time, now time.Time) string {
+	d := now.Sub(t)
+
+	suffix := "ago"
+	if d < 0 {
+		d = -d
+		suffix = "from now"
+	}
+
+	var n int64
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int64(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int64(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int64(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int64(d/(365*24*time.Hour)), "year"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("%d %s %s", n, unit, suffix)
+}
@@ -0,0 +1,51 @@
+package papertool
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJsonTime(t *testing.T) {
+	var v struct {
+		Millis JsonTime `json:"millis"`
+		RFC    JsonTime `json:"rfc"`
+	}
+
+	err := json.Unmarshal([]byte(`{"millis": 1746123438376, "rfc": "2025-05-01T18:17:18.376Z"}`), &v)
+	if err != nil {
+		t.Fatalf("1: %v", err)
+	}
+
+	want := time.Date(2025, 5, 1, 18, 17, 18, 376000000, time.UTC)
+	if !time.Time(v.Millis).Equal(want) {
+		t.Fatalf("2: expected %v got %v", want, time.Time(v.Millis))
+	}
+	if !time.Time(v.RFC).Equal(want) {
+		t.Fatalf("3: expected %v got %v", want, time.Time(v.RFC))
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(-1 * time.Minute), "1 minute ago"},
+		{now.Add(-5 * time.Hour), "5 hours ago"},
+		{now.Add(-3 * 24 * time.Hour), "3 days ago"},
+		{now.Add(-70 * 24 * time.Hour), "2 months ago"},
+		{now.Add(-800 * 24 * time.Hour), "2 years ago"},
+		{now.Add(2 * time.Hour), "2 hours from now"},
+	}
+
+	for _, test := range tests {
+		got := RelativeTime(test.t, now)
+		if got != test.want {
+			t.Fatalf("%v: expected %q got %q", test.t, test.want, got)
+		}
+	}
+}
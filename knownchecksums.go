@@ -0,0 +1,264 @@
+package papertool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KnownChecksums, when set, is consulted by GetBuild, GetBuilds and
+// Download. The first checksum reported for a build is recorded; if the
+// API later reports a different one for the same build the database's
+// Mode decides whether that's an error or just a warning.
+//
+// This is trust on first use: it can't tell whether the first checksum
+// was genuine, only that it hasn't changed since.
+var KnownChecksums *ChecksumDB
+
+type PinMode int
+
+const (
+	PinWarn   PinMode = iota // print a warning and carry on
+	PinStrict                // fail with a *ChecksumChangedError
+)
+
+type KnownChecksum struct {
+	Project   string    `json:"project"`
+	Version   string    `json:"version"`
+	Build     string    `json:"build"`
+	Sha256    string    `json:"sha256"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// ChecksumChangedError reports a build whose checksum differs from the one
+// recorded the first time it was seen.
+type ChecksumChangedError struct {
+	Project  string
+	Version  string
+	Build    string
+	Known    string
+	Reported string
+}
+
+func (e *ChecksumChangedError) Error() string {
+	return fmt.Sprintf("%s %s build %s: sha256 %s differs from %s recorded when first seen", e.Project, e.Version, e.Build, e.Reported, e.Known)
+}
+
+// ChecksumDB is a small JSON file of known build checksums.
+type ChecksumDB struct {
+	Mode PinMode
+
+	// Warnf prints warnings in PinWarn mode. Defaults to stderr.
+	Warnf func(format string, args ...interface{})
+
+	mu      sync.Mutex
+	path    string
+	entries map[string]*KnownChecksum
+}
+
+// DefaultChecksumDBPath returns known-checksums.json in the user's config
+// directory, e.g. ~/.config/papertool/known-checksums.json.
+func DefaultChecksumDBPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "papertool", "known-checksums.json"), nil
+}
+
+// OpenChecksumDB loads the database at path. A missing file is an empty
+// database; it's created on the first Record.
+func OpenChecksumDB(path string, mode PinMode) (*ChecksumDB, error) {
+	db := &ChecksumDB{
+		Mode: mode,
+		Warnf: func(format string, args ...interface{}) {
+			fmt.Fprintf(os.Stderr, "WARNING: "+format+"\n", args...)
+		},
+		path:    path,
+		entries: map[string]*KnownChecksum{},
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return db, nil
+		}
+		return nil, err
+	}
+
+	entries := []*KnownChecksum{}
+	err = unmarshal(raw, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, e := range entries {
+		db.entries[checksumKey(e.Project, e.Version, e.Build)] = e
+	}
+
+	return db, nil
+}
+
+func checksumKey(project string, version string, build string) string {
+	return project + "/" + version + "/" + build
+}
+
+// Lookup returns the recorded checksum for a build, if any.
+func (db *ChecksumDB) Lookup(project string, version string, build string) *KnownChecksum {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.entries[checksumKey(project, version, build)]
+}
+
+// Check compares sha256 against what's recorded for the build, recording
+// it if this is the first time the build has been seen. Only concrete
+// numeric build ids are pinned; aliases like "latest" move from one
+// build to the next, so they're ignored.
+func (db *ChecksumDB) Check(project string, version string, build string, sha256 string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	added, err := db.check(project, version, build, sha256)
+	if added {
+		serr := db.save()
+		if err == nil {
+			err = serr
+		}
+	}
+
+	return err
+}
+
+// CheckBuild is Check for a build's artifact.
+func (db *ChecksumDB) CheckBuild(project string, version string, b *Build) error {
+	return db.CheckBuilds(project, version, []*Build{b})
+}
+
+// CheckBuilds is CheckBuild for a whole listing. Newly seen builds are
+// all recorded before the database is saved, once. In PinStrict mode the
+// first changed checksum is returned.
+func (db *ChecksumDB) CheckBuilds(project string, version string, builds []*Build) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var first error
+	save := false
+	for _, b := range builds {
+		if b == nil || b.Build == nil || b.Artifact == nil || b.Artifact.Application == nil || b.Artifact.Application.Sha256 == nil {
+			continue
+		}
+
+		added, err := db.check(project, version, String(b.Build), *b.Artifact.Application.Sha256)
+		save = save || added
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+
+	if save {
+		err := db.save()
+		if first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// check is Check without saving; added reports whether a new entry was
+// recorded. Called with db.mu held.
+func (db *ChecksumDB) check(project string, version string, build string, sha256 string) (bool, error) {
+	if sha256 == "" {
+		return false, nil
+	}
+
+	_, err := strconv.ParseUint(build, 10, 64)
+	if err != nil {
+		return false, nil
+	}
+
+	key := checksumKey(project, version, build)
+
+	known, ok := db.entries[key]
+	if !ok {
+		db.entries[key] = &KnownChecksum{
+			Project:   project,
+			Version:   version,
+			Build:     build,
+			Sha256:    sha256,
+			FirstSeen: time.Now().UTC(),
+		}
+		return true, nil
+	}
+
+	if known.Sha256 == sha256 {
+		return false, nil
+	}
+
+	cerr := &ChecksumChangedError{
+		Project:  project,
+		Version:  version,
+		Build:    build,
+		Known:    known.Sha256,
+		Reported: sha256,
+	}
+
+	if db.Mode == PinStrict {
+		return false, cerr
+	}
+
+	db.Warnf("%v", cerr)
+
+	return false, nil
+}
+
+// save writes the database out, via a temporary file so a crash can't
+// leave it truncated. Called with db.mu held.
+func (db *ChecksumDB) save() error {
+	entries := make([]*KnownChecksum, 0, len(db.entries))
+	for _, e := range db.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return checksumKey(entries[i].Project, entries[i].Version, entries[i].Build) < checksumKey(entries[j].Project, entries[j].Version, entries[j].Build)
+	})
+
+	raw, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(db.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// A unique temporary file, so concurrent papertool processes don't
+	// write over each other's half written copies.
+	f, err := os.CreateTemp(filepath.Dir(db.path), filepath.Base(db.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(raw)
+	cerr := f.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), db.path)
+}
@@ -0,0 +1,151 @@
+package papertool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testChecksumDB(t *testing.T, mode PinMode) (*ChecksumDB, string, *[]string) {
+	path := filepath.Join(t.TempDir(), "known-checksums.json")
+
+	db, err := OpenChecksumDB(path, mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := &[]string{}
+	db.Warnf = func(format string, args ...interface{}) {
+		*warnings = append(*warnings, fmt.Sprintf(format, args...))
+	}
+
+	return db, path, warnings
+}
+
+func TestChecksumDBFirstSeenAndMatch(t *testing.T) {
+	db, path, warnings := testChecksumDB(t, PinStrict)
+
+	err := db.Check("paper", "1.21.4", "232", "aaaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	known := db.Lookup("paper", "1.21.4", "232")
+	if known == nil || known.Sha256 != "aaaa" || known.FirstSeen.IsZero() {
+		t.Fatalf("first seen: got %+v", known)
+	}
+
+	// Recorded on disk.
+	reopened, err := OpenChecksumDB(path, PinStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Lookup("paper", "1.21.4", "232") == nil {
+		t.Fatalf("not saved")
+	}
+
+	err = reopened.Check("paper", "1.21.4", "232", "aaaa")
+	if err != nil {
+		t.Fatalf("match: %v", err)
+	}
+	if len(*warnings) != 0 {
+		t.Fatalf("match: unexpected warnings %q", *warnings)
+	}
+}
+
+func TestChecksumDBChanged(t *testing.T) {
+	db, _, warnings := testChecksumDB(t, PinWarn)
+
+	err := db.Check("paper", "1.21.4", "232", "aaaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Check("paper", "1.21.4", "232", "bbbb")
+	if err != nil {
+		t.Fatalf("warn mode: %v", err)
+	}
+	if len(*warnings) != 1 {
+		t.Fatalf("warn mode: got warnings %q", *warnings)
+	}
+
+	// The first checksum stays recorded.
+	if db.Lookup("paper", "1.21.4", "232").Sha256 != "aaaa" {
+		t.Fatalf("changed checksum replaced the known one")
+	}
+
+	db.Mode = PinStrict
+	err = db.Check("paper", "1.21.4", "232", "bbbb")
+	var changed *ChecksumChangedError
+	if !errors.As(err, &changed) || changed.Known != "aaaa" || changed.Reported != "bbbb" {
+		t.Fatalf("strict mode: got %v", err)
+	}
+}
+
+func TestChecksumDBIgnoresAliases(t *testing.T) {
+	db, path, _ := testChecksumDB(t, PinStrict)
+
+	for _, build := range []string{"latest", "first", "", "-1"} {
+		err := db.Check("paper", "1.21.4", build, "aaaa")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.Check("paper", "1.21.4", build, "bbbb")
+		if err != nil {
+			t.Fatalf("%q: %v", build, err)
+		}
+		if db.Lookup("paper", "1.21.4", build) != nil {
+			t.Fatalf("%q: pinned", build)
+		}
+	}
+
+	_, err := os.Stat(path)
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected nothing saved, got %v", err)
+	}
+}
+
+func TestChecksumDBCheckBuilds(t *testing.T) {
+	db, path, _ := testChecksumDB(t, PinStrict)
+
+	build := func(id float64, sha string) *Build {
+		return &Build{
+			Build:    &id,
+			Artifact: &Artifact{Application: &Application{Sha256: &sha}},
+		}
+	}
+
+	builds := []*Build{build(1, "a1"), build(2, "a2"), {}, build(3, "a3")}
+	err := db.CheckBuilds("paper", "1.21.4", builds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenChecksumDB(path, PinStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if reopened.Lookup("paper", "1.21.4", id) == nil {
+			t.Fatalf("build %s not saved", id)
+		}
+	}
+
+	// A changed build fails, but new builds in the same listing are
+	// still recorded.
+	err = reopened.CheckBuilds("paper", "1.21.4", []*Build{build(2, "changed"), build(4, "a4")})
+	var changed *ChecksumChangedError
+	if !errors.As(err, &changed) || changed.Build != "2" {
+		t.Fatalf("changed: got %v", err)
+	}
+	if reopened.Lookup("paper", "1.21.4", "4") == nil {
+		t.Fatalf("build 4 not recorded")
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp"))
+	if len(leftovers) != 0 {
+		t.Fatalf("temporary files left behind: %q", leftovers)
+	}
+}
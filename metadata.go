@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 /*
@@ -23,6 +24,14 @@ import (
  *
  * v3 endpoints:
  *
+ *   GET https://fill.papermc.io/v3/projects
+ *   {
+ *     "projects": [
+ *       { "project": { "id": "paper", "name": "Paper" }, "versions": { ... } },
+ *       ...
+ *     ]
+ *   }
+ *
  *   GET https://fill.papermc.io/v3/projects/${PROJECT}
  *   {
  *     "project":  { "id": "velocity", "name": "Velocity" },
@@ -91,13 +100,18 @@ type Build struct {
 	Promoted    *bool     `json:"promoted"`
 	Changes     []*Change `json:"changes"`
 	Artifact    *Artifact `json:"downloads"`
-	raw         []byte
+	// Timestamp is Time parsed. Zero if the API didn't give a time.
+	Timestamp time.Time `json:"-"`
+	raw       []byte
 }
 
 type Change struct {
 	Commit  *string `json:"commit"`
 	Summary *string `json:"summary"`
 	Message *string `json:"message"`
+	Time    *string `json:"time,omitempty"`
+	// Timestamp is Time parsed. Zero if the API didn't give a time.
+	Timestamp time.Time `json:"-"`
 }
 
 type Artifact struct {
@@ -107,12 +121,32 @@ type Artifact struct {
 type Application struct {
 	Name   *string `json:"name"`
 	Sha256 *string `json:"sha256"`
+	// Checksums holds every checksum the API published for the
+	// artifact, keyed by algorithm. Sha256 is kept for existing callers.
+	Checksums Checksums `json:"checksums,omitempty"`
+	// Size is the advertised size of the artifact in bytes, from the v3
+	// API. Nil if unknown.
+	Size *int64 `json:"size,omitempty"`
 	// URL is the direct download URL provided by the v3 API. Set by the
 	// translation layer; empty for callers that constructed an Artifact
 	// some other way.
 	URL *string `json:"url,omitempty"`
 }
 
+// Project is one entry from GetProjects. Unlike the types above it has no
+// v2 ancestor, so its fields are plain values.
+type Project struct {
+	ID            string
+	Name          string
+	VersionGroups []string
+	Versions      []string // oldest first
+}
+
+type Projects struct {
+	Projects []*Project
+	raw      []byte
+}
+
 type MetadataSyntaxError struct {
 	Raw    string
 	msg    string
@@ -133,6 +167,10 @@ type v3Project struct {
 	Versions map[string][]string `json:"versions"`
 }
 
+type v3Projects struct {
+	Projects []v3Project `json:"projects"`
+}
+
 type v3Build struct {
 	ID        json.Number             `json:"id"`
 	Time      string                  `json:"time"`
@@ -149,9 +187,7 @@ type v3Commit struct {
 
 type v3Download struct {
 	Name      string `json:"name"`
-	Checksums struct {
-		Sha256 string `json:"sha256"`
-	} `json:"checksums"`
+	Checksums map[string]string `json:"checksums"`
 	Size int64  `json:"size"`
 	URL  string `json:"url"`
 }
@@ -175,23 +211,80 @@ func GetVersions(src *url.URL, project string) (*Versions, error) {
 		raw:         raw,
 	}
 
-	groups := make([]string, 0, len(v3.Versions))
-	for g := range v3.Versions {
+	versions.VersionGroups, versions.Versions = flattenVersions(v3.Versions)
+
+	return versions, nil
+}
+
+// GetProjects lists every project the server knows about.
+func GetProjects(src *url.URL) (*Projects, error) {
+	u := fmt.Sprintf("%s/v3/projects", src.String())
+
+	v3 := &v3Projects{}
+	raw, err := fetch(u, v3)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := &Projects{
+		raw: raw,
+	}
+
+	for _, p := range v3.Projects {
+		project := &Project{
+			ID:   p.Project.ID,
+			Name: p.Project.Name,
+		}
+		project.VersionGroups, project.Versions = flattenVersions(p.Versions)
+		projects.Projects = append(projects.Projects, project)
+	}
+
+	sort.Slice(projects.Projects, func(i, j int) bool { return projects.Projects[i].ID < projects.Projects[j].ID })
+
+	return projects, nil
+}
+
+// Find returns the project with the given id, or nil.
+func (projects *Projects) Find(id string) *Project {
+	for _, p := range projects.Projects {
+		if p.ID == id {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// IDs returns the id of every project.
+func (projects *Projects) IDs() []string {
+	ids := make([]string, 0, len(projects.Projects))
+	for _, p := range projects.Projects {
+		ids = append(ids, p.ID)
+	}
+
+	return ids
+}
+
+// flattenVersions sorts the v3 version groups oldest-first and flattens
+// their versions into a single oldest-first list.
+func flattenVersions(v3 map[string][]string) ([]string, []string) {
+	groups := make([]string, 0, len(v3))
+	for g := range v3 {
 		groups = append(groups, g)
 	}
 	sort.Slice(groups, func(i, j int) bool { return semverLess(groups[i], groups[j]) })
-	versions.VersionGroups = groups
 
+	versions := []string{}
 	for _, g := range groups {
-		gv := v3.Versions[g]
+		gv := v3[g]
 		// v3 lists versions newest-first within each group; reverse so the
 		// flattened list is oldest-first (newest at len-1), matching v2.
 		for i := len(gv) - 1; i >= 0; i-- {
-			versions.Versions = append(versions.Versions, gv[i])
+			versions = append(versions, gv[i])
 		}
 	}
 
-	return versions, nil
+	return groups, versions
 }
 
 func GetBuilds(src *url.URL, project string, version string) (*Builds, error) {
@@ -221,6 +314,13 @@ func GetBuilds(src *url.URL, project string, version string) (*Builds, error) {
 		builds.Builds = append(builds.Builds, b)
 	}
 
+	if KnownChecksums != nil {
+		err = KnownChecksums.CheckBuilds(project, version, builds.Builds)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return builds, nil
 }
 
@@ -240,6 +340,14 @@ func GetBuild(src *url.URL, project string, version string, build string) (*Buil
 	pid := project
 	b.ProjectID = &pid
 	b.raw = raw
+
+	if KnownChecksums != nil {
+		err = KnownChecksums.CheckBuild(project, version, b)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return b, nil
 }
 
@@ -258,16 +366,28 @@ func v3BuildToLegacy(v3 *v3Build) (*Build, error) {
 		Time:    &t,
 		Channel: &ch,
 	}
+	// A time we can't parse shouldn't cost the caller the whole
+	// listing: Timestamp stays zero and Time keeps the raw string.
+	if ts, err := ParseTime(t); err == nil {
+		b.Timestamp = ts
+	}
+
 	for _, c := range v3.Commits {
 		sha := c.Sha
 		// v3 doesn't separate summary from message; populate both with
 		// message so callers checking either field still see content.
 		msg := c.Message
-		b.Changes = append(b.Changes, &Change{
+		ct := c.Time
+		change := &Change{
 			Commit:  &sha,
 			Summary: &msg,
 			Message: &msg,
-		})
+			Time:    &ct,
+		}
+		if ts, err := ParseTime(ct); err == nil {
+			change.Timestamp = ts
+		}
+		b.Changes = append(b.Changes, change)
 	}
 
 	var dl *v3Download
@@ -291,15 +411,20 @@ func v3BuildToLegacy(v3 *v3Build) (*Build, error) {
 
 	if dl != nil {
 		name := dl.Name
-		sha := dl.Checksums.Sha256
+		sha := dl.Checksums["sha256"]
 		dlurl := dl.URL
 		b.Artifact = &Artifact{
 			Application: &Application{
-				Name:   &name,
-				Sha256: &sha,
-				URL:    &dlurl,
+				Name:      &name,
+				Sha256:    &sha,
+				Checksums: Checksums(dl.Checksums),
+				URL:       &dlurl,
 			},
 		}
+		if dl.Size > 0 {
+			size := dl.Size
+			b.Artifact.Application.Size = &size
+		}
 	}
 
 	return b, nil
@@ -337,12 +462,16 @@ func fetch(src string, result any) ([]byte, error) {
 	}
 
 	if response.StatusCode < 200 || response.StatusCode >= 300 {
-		return nil, fmt.Errorf("%s: status %d: %s", src, response.StatusCode, strings.TrimSpace(string(body)))
+		return nil, statusError(src, response, body)
 	}
 
 	err = unmarshal(body, result)
 	if err != nil {
-		return nil, err
+		_, isSyntaxError := err.(*MetadataSyntaxError)
+		if isSyntaxError {
+			return nil, err
+		}
+		return nil, &DecodeError{URL: src, Err: err}
 	}
 
 	return body, nil
@@ -365,6 +494,16 @@ func unmarshal(raw []byte, dst any) error {
 	return nil
 }
 
+// Age returns how long ago the build was made, or 0 if its time is
+// unknown.
+func (build *Build) Age() time.Duration {
+	if build.Timestamp.IsZero() {
+		return 0
+	}
+
+	return time.Since(build.Timestamp)
+}
+
 func (builds *Builds) FindBuildIndex(build string) int {
 	if len(builds.Builds) == 0 {
 		return -1
@@ -387,6 +526,24 @@ func (builds *Builds) FindBuildIndex(build string) int {
 	return -1
 }
 
+// InChannel returns a copy of builds containing only those in channel,
+// compared case-insensitively. An empty channel returns builds unchanged.
+func (builds *Builds) InChannel(channel string) *Builds {
+	if channel == "" {
+		return builds
+	}
+
+	filtered := *builds
+	filtered.Builds = nil
+	for _, b := range builds.Builds {
+		if b.Channel != nil && strings.EqualFold(*b.Channel, channel) {
+			filtered.Builds = append(filtered.Builds, b)
+		}
+	}
+
+	return &filtered
+}
+
 func (builds *Builds) FindBuild(build string) *Build {
 	i := builds.FindBuildIndex(build)
 	if i < 0 {
@@ -396,6 +553,10 @@ func (builds *Builds) FindBuild(build string) *Build {
 	return builds.Builds[i]
 }
 
+func (projects *Projects) Raw() []byte {
+	return projects.raw
+}
+
 func (versions *Versions) Raw() []byte {
 	return versions.raw
 }
@@ -0,0 +1,35 @@
+package papertool
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestV3BuildBadTime(t *testing.T) {
+	v3 := &v3Build{
+		ID:      json.Number("12"),
+		Time:    "last tuesday",
+		Channel: "STABLE",
+		Commits: []v3Commit{{Sha: "abc", Time: "also bad", Message: "msg"}},
+	}
+
+	b, err := v3BuildToLegacy(v3)
+	if err != nil {
+		t.Fatalf("1: %v", err)
+	}
+	if !b.Timestamp.IsZero() || String(b.Time) != "last tuesday" {
+		t.Fatalf("2: bad time %v %q", b.Timestamp, String(b.Time))
+	}
+	if len(b.Changes) != 1 || !b.Changes[0].Timestamp.IsZero() || String(b.Changes[0].Time) != "also bad" {
+		t.Fatalf("3: bad change %+v", b.Changes)
+	}
+
+	v3.Time = "2025-05-01T18:17:18.376Z"
+	b, err = v3BuildToLegacy(v3)
+	if err != nil {
+		t.Fatalf("4: %v", err)
+	}
+	if b.Timestamp.IsZero() {
+		t.Fatalf("5: expected timestamp")
+	}
+}
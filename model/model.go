@@ -0,0 +1,314 @@
+// Package model is a plain-value view of papertool's metadata.
+//
+// The types in package papertool mirror the old v2 JSON, so every field is
+// a pointer and build numbers are float64. The types here have plain
+// strings, int build numbers, parsed times, a Channel enum and a Sha256
+// type. Use the From* helpers to convert what papertool's fetchers return,
+// so callers can move over one call site at a time.
+package model
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/tadhunt/papertool"
+	"strings"
+	"time"
+)
+
+type Versions struct {
+	ProjectID     string
+	ProjectName   string
+	VersionGroups []string
+	Versions      []string // oldest first
+}
+
+type Builds struct {
+	ProjectID   string
+	ProjectName string
+	Version     string
+	Builds      []*Build // oldest first
+}
+
+type Build struct {
+	ProjectID   string
+	ProjectName string
+	Number      int
+	Time        time.Time
+	Channel     Channel
+	Promoted    bool
+	Changes     []*Change
+	Application *Application // nil if the build has no server download
+}
+
+type Change struct {
+	Commit  string
+	Summary string
+	Message string
+	Time    time.Time
+}
+
+type Application struct {
+	Name      string
+	Sha256    Sha256
+	Checksums papertool.Checksums
+	Size      int64 // 0 if unknown
+	URL       string
+}
+
+// --- Channel. ---
+
+// Channel is a build's release channel. Upstream adds channels from time
+// to time, so a Channel may also hold a name not listed below, exactly as
+// the API reported it; use Known to tell.
+type Channel string
+
+const (
+	ChannelUnknown     Channel = ""
+	ChannelAlpha       Channel = "ALPHA"
+	ChannelBeta        Channel = "BETA"
+	ChannelStable      Channel = "STABLE"
+	ChannelRecommended Channel = "RECOMMENDED"
+)
+
+// ParseChannel parses a v3 channel name. The v2 names "default" and
+// "experimental" are accepted as STABLE and BETA. Anything else is an
+// error; see UnmarshalText for the lenient version.
+func ParseChannel(s string) (Channel, error) {
+	switch strings.ToUpper(s) {
+	case "ALPHA":
+		return ChannelAlpha, nil
+	case "BETA", "EXPERIMENTAL":
+		return ChannelBeta, nil
+	case "STABLE", "DEFAULT":
+		return ChannelStable, nil
+	case "RECOMMENDED":
+		return ChannelRecommended, nil
+	}
+
+	return ChannelUnknown, fmt.Errorf("unknown channel %q", s)
+}
+
+// Known reports whether c is one of the channels listed above.
+func (c Channel) Known() bool {
+	switch c {
+	case ChannelAlpha, ChannelBeta, ChannelStable, ChannelRecommended:
+		return true
+	}
+
+	return false
+}
+
+func (c Channel) String() string {
+	if c == ChannelUnknown {
+		return "UNKNOWN"
+	}
+
+	return string(c)
+}
+
+func (c Channel) MarshalText() ([]byte, error) {
+	return []byte(c), nil
+}
+
+// UnmarshalText accepts anything: known names are normalized as by
+// ParseChannel and unknown ones are kept as they are.
+func (c *Channel) UnmarshalText(text []byte) error {
+	*c = channelOf(string(text))
+
+	return nil
+}
+
+func channelOf(s string) Channel {
+	ch, err := ParseChannel(s)
+	if err != nil {
+		return Channel(s)
+	}
+
+	return ch
+}
+
+// --- Sha256. ---
+
+type Sha256 [32]byte
+
+func ParseSha256(s string) (Sha256, error) {
+	var sum Sha256
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return sum, fmt.Errorf("bad sha256 %q: %v", s, err)
+	}
+	if len(b) != len(sum) {
+		return sum, fmt.Errorf("bad sha256 %q: wrong length", s)
+	}
+	copy(sum[:], b)
+
+	return sum, nil
+}
+
+func (s Sha256) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func (s Sha256) IsZero() bool {
+	return s == Sha256{}
+}
+
+func (s Sha256) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Sha256) UnmarshalText(text []byte) error {
+	sum, err := ParseSha256(string(text))
+	if err != nil {
+		return err
+	}
+	*s = sum
+
+	return nil
+}
+
+// --- Conversions from package papertool. ---
+
+func FromVersions(v *papertool.Versions) *Versions {
+	return &Versions{
+		ProjectID:     str(v.ProjectID),
+		ProjectName:   str(v.ProjectName),
+		VersionGroups: append([]string{}, v.VersionGroups...),
+		Versions:      append([]string{}, v.Versions...),
+	}
+}
+
+func FromBuilds(b *papertool.Builds) (*Builds, error) {
+	builds := &Builds{
+		ProjectID:   str(b.ProjectID),
+		ProjectName: str(b.ProjectName),
+		Version:     str(b.Version),
+	}
+
+	for _, lb := range b.Builds {
+		mb, err := FromBuild(lb)
+		if err != nil {
+			return nil, err
+		}
+		builds.Builds = append(builds.Builds, mb)
+	}
+
+	return builds, nil
+}
+
+func FromBuild(b *papertool.Build) (*Build, error) {
+	build := &Build{
+		ProjectID:   str(b.ProjectID),
+		ProjectName: str(b.ProjectName),
+	}
+
+	if b.Build != nil {
+		build.Number = int(*b.Build)
+	}
+
+	build.Time = b.Timestamp
+	if build.Time.IsZero() && b.Time != nil {
+		if t, err := papertool.ParseTime(*b.Time); err == nil {
+			build.Time = t
+		}
+	}
+
+	// Channels added upstream after this was written shouldn't make
+	// the whole build unusable; they're kept as reported.
+	if b.Channel != nil {
+		build.Channel = channelOf(*b.Channel)
+	}
+
+	if b.Promoted != nil {
+		build.Promoted = *b.Promoted
+	}
+
+	for _, c := range b.Changes {
+		build.Changes = append(build.Changes, &Change{
+			Commit:  str(c.Commit),
+			Summary: str(c.Summary),
+			Message: str(c.Message),
+			Time:    c.Timestamp,
+		})
+	}
+
+	if b.Artifact != nil && b.Artifact.Application != nil {
+		app, err := FromApplication(b.Artifact.Application)
+		if err != nil {
+			return nil, fmt.Errorf("build %d: %v", build.Number, err)
+		}
+		build.Application = app
+	}
+
+	return build, nil
+}
+
+func FromApplication(a *papertool.Application) (*Application, error) {
+	app := &Application{
+		Name:      str(a.Name),
+		Checksums: a.ExpectedChecksums(),
+		URL:       str(a.URL),
+	}
+
+	if a.Size != nil {
+		app.Size = *a.Size
+	}
+
+	if a.Sha256 != nil && *a.Sha256 != "" {
+		sum, err := ParseSha256(*a.Sha256)
+		if err != nil {
+			return nil, err
+		}
+		app.Sha256 = sum
+	}
+
+	return app, nil
+}
+
+// Artifact converts back to the papertool type, for passing to Download.
+func (a *Application) Artifact() *papertool.Artifact {
+	app := &papertool.Application{
+		Name:      &a.Name,
+		Checksums: a.Checksums,
+	}
+
+	if !a.Sha256.IsZero() {
+		sum := a.Sha256.String()
+		app.Sha256 = &sum
+	}
+
+	if a.Size > 0 {
+		app.Size = &a.Size
+	}
+
+	if a.URL != "" {
+		app.URL = &a.URL
+	}
+
+	return &papertool.Artifact{Application: app}
+}
+
+// Age returns how long ago the build was made, or 0 if its time is
+// unknown.
+func (b *Build) Age() time.Duration {
+	if b.Time.IsZero() {
+		return 0
+	}
+
+	return time.Since(b.Time)
+}
+
+// BuildString returns the build number the way papertool's fetchers and
+// Download expect it.
+func (b *Build) BuildString() string {
+	return fmt.Sprintf("%d", b.Number)
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"github.com/tadhunt/papertool"
+	"testing"
+	"time"
+)
+
+func TestFromBuild(t *testing.T) {
+	id := float64(594)
+	tm := "2026-05-01T18:17:18.376Z"
+	ch := "STABLE"
+	name := "velocity-3.5.0-SNAPSHOT-594.jar"
+	sha := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	size := int64(18881365)
+	commit := "abc123"
+	msg := "Fix things"
+
+	lb := &papertool.Build{
+		Build:   &id,
+		Time:    &tm,
+		Channel: &ch,
+		Changes: []*papertool.Change{{Commit: &commit, Summary: &msg, Message: &msg}},
+		Artifact: &papertool.Artifact{
+			Application: &papertool.Application{Name: &name, Sha256: &sha, Size: &size},
+		},
+	}
+
+	b, err := FromBuild(lb)
+	if err != nil {
+		t.Fatalf("1: %v", err)
+	}
+
+	if b.Number != 594 || b.BuildString() != "594" {
+		t.Fatalf("2: bad build number %d", b.Number)
+	}
+	if !b.Time.Equal(time.Date(2026, 5, 1, 18, 17, 18, 376000000, time.UTC)) {
+		t.Fatalf("3: bad time %v", b.Time)
+	}
+	if b.Channel != ChannelStable {
+		t.Fatalf("4: bad channel %v", b.Channel)
+	}
+	if len(b.Changes) != 1 || b.Changes[0].Commit != commit {
+		t.Fatalf("5: bad changes %v", b.Changes)
+	}
+	if b.Application == nil || b.Application.Sha256.String() != sha || b.Application.Size != size {
+		t.Fatalf("6: bad application %+v", b.Application)
+	}
+
+	a := b.Application.Artifact()
+	if papertool.String(a.Application.Sha256) != sha || papertool.String(a.Application.Name) != name {
+		t.Fatalf("7: bad round trip %+v", a.Application)
+	}
+
+	bad := "not-a-channel"
+	lb.Channel = &bad
+	b, err = FromBuild(lb)
+	if err != nil {
+		t.Fatalf("8: unknown channel should not fail: %v", err)
+	}
+	if b.Channel != Channel(bad) || b.Channel.Known() || b.Channel.String() != bad {
+		t.Fatalf("9: expected the raw channel, got %v", b.Channel)
+	}
+}
+
+func TestChannelText(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected Channel
+		known    bool
+	}{
+		{"STABLE", ChannelStable, true},
+		{"default", ChannelStable, true},
+		{"experimental", ChannelBeta, true},
+		{"RECOMMENDED", ChannelRecommended, true},
+		{"NIGHTLY", Channel("NIGHTLY"), false},
+		{"", ChannelUnknown, false},
+	}
+
+	for _, test := range tests {
+		var c Channel
+		err := c.UnmarshalText([]byte(test.text))
+		if err != nil {
+			t.Fatalf("1: %q: %v", test.text, err)
+		}
+		if c != test.expected || c.Known() != test.known {
+			t.Fatalf("2: %q: got %v", test.text, c)
+		}
+
+		text, err := c.MarshalText()
+		if err != nil {
+			t.Fatalf("3: %q: %v", test.text, err)
+		}
+		if string(text) != string(test.expected) {
+			t.Fatalf("4: %q: marshaled as %q", test.text, text)
+		}
+	}
+
+	// Parsing, e.g. a command line flag, stays strict.
+	_, err := ParseChannel("NIGHTLY")
+	if err == nil {
+		t.Fatalf("5: NIGHTLY parsed")
+	}
+}
@@ -0,0 +1,221 @@
+package papertool
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/*
+ * Some plugins are only published on Modrinth:
+ *
+ *   GET https://api.modrinth.com/v2/project/${SLUG}/version?loaders=["paper"]&game_versions=["1.21.4"]
+ *   [
+ *     {
+ *       "id":             "IZskON6d",
+ *       "project_id":     "P1OZGk5p",
+ *       "name":           "Example 2.1.0",
+ *       "version_number": "2.1.0",
+ *       "version_type":   "release",
+ *       "date_published": "2025-01-10T12:00:00.000000Z",
+ *       "loaders":        ["paper", "spigot"],
+ *       "game_versions":  ["1.21.3", "1.21.4"],
+ *       "changelog":      "...",
+ *       "files": [
+ *         {
+ *           "url":      "https://cdn.modrinth.com/data/P1OZGk5p/versions/IZskON6d/example-2.1.0.jar",
+ *           "filename": "example-2.1.0.jar",
+ *           "primary":  true,
+ *           "size":     123456,
+ *           "hashes":   { "sha512": "...", "sha1": "..." }
+ *         }
+ *       ]
+ *     },
+ *     ...
+ *   ]
+ *
+ * Versions come back newest first. A version may carry several files;
+ * the one marked primary is the plugin.
+ */
+
+const ModrinthServer = "https://api.modrinth.com"
+
+// ModrinthLoader returns the Modrinth loader for a papermc.io project or
+// Hangar platform, e.g. "paper" or PAPER is "paper".
+func ModrinthLoader(project string) string {
+	return strings.ToLower(project)
+}
+
+type ModrinthVersion struct {
+	Slug          string // the project the version belongs to
+	ID            string
+	Name          string
+	VersionNumber string
+	VersionType   string // release, beta or alpha
+	Published     time.Time
+	Loaders       []string
+	GameVersions  []string
+	Changelog     string
+	Files         []*ModrinthFile
+}
+
+type ModrinthFile struct {
+	FileName string
+	URL      string
+	Size     int64
+	Primary  bool
+	Hashes   Checksums
+}
+
+type modrinthVersion struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	VersionNumber string   `json:"version_number"`
+	VersionType   string   `json:"version_type"`
+	DatePublished string   `json:"date_published"`
+	Loaders       []string `json:"loaders"`
+	GameVersions  []string `json:"game_versions"`
+	Changelog     string   `json:"changelog"`
+	Files         []struct {
+		URL      string            `json:"url"`
+		Filename string            `json:"filename"`
+		Primary  bool              `json:"primary"`
+		Size     int64             `json:"size"`
+		Hashes   map[string]string `json:"hashes"`
+	} `json:"files"`
+}
+
+// Modrinth is a client for the Modrinth v2 API.
+type Modrinth struct {
+	server *url.URL
+}
+
+func NewModrinth(server *url.URL) *Modrinth {
+	return &Modrinth{
+		server: server,
+	}
+}
+
+// ListVersions returns the versions of the project slug, newest first.
+// loader and gameVersion narrow the list to versions that support them;
+// either may be empty.
+func (m *Modrinth) ListVersions(slug string, loader string, gameVersion string) ([]*ModrinthVersion, error) {
+	params := url.Values{}
+	if loader != "" {
+		params.Set("loaders", jsonList(loader))
+	}
+	if gameVersion != "" {
+		params.Set("game_versions", jsonList(gameVersion))
+	}
+
+	u := fmt.Sprintf("%s/v2/project/%s/version", m.server.String(), url.PathEscape(slug))
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	var mvs []modrinthVersion
+	_, err := fetch(u, &mvs)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []*ModrinthVersion{}
+	for i := range mvs {
+		mv := &mvs[i]
+		v := &ModrinthVersion{
+			Slug:          slug,
+			ID:            mv.ID,
+			Name:          mv.Name,
+			VersionNumber: mv.VersionNumber,
+			VersionType:   mv.VersionType,
+			Loaders:       mv.Loaders,
+			GameVersions:  mv.GameVersions,
+			Changelog:     mv.Changelog,
+		}
+		v.Published, err = ParseTime(mv.DatePublished)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", slug, mv.VersionNumber, err)
+		}
+		for _, f := range mv.Files {
+			v.Files = append(v.Files, &ModrinthFile{
+				FileName: f.Filename,
+				URL:      f.URL,
+				Size:     f.Size,
+				Primary:  f.Primary,
+				Hashes:   Checksums(f.Hashes),
+			})
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
+
+// jsonList encodes a single string as the JSON array Modrinth's filter
+// parameters expect.
+func jsonList(s string) string {
+	raw, _ := json.Marshal([]string{s})
+	return string(raw)
+}
+
+// PrimaryFile returns the file marked primary, or the only file if none
+// is marked.
+func (v *ModrinthVersion) PrimaryFile() (*ModrinthFile, error) {
+	for _, f := range v.Files {
+		if f.Primary {
+			return f, nil
+		}
+	}
+
+	if len(v.Files) == 1 {
+		return v.Files[0], nil
+	}
+
+	return nil, fmt.Errorf("%s %s: no primary file among %d", v.Slug, v.VersionNumber, len(v.Files))
+}
+
+// Artifact returns the version's primary file in the shape DownloadPlugin
+// takes. The file must publish a sha512, which is what it's verified
+// against along with any other known hashes.
+func (v *ModrinthVersion) Artifact() (*Artifact, error) {
+	f, err := v.PrimaryFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Hashes["sha512"] == "" {
+		return nil, fmt.Errorf("%s %s: %s has no sha512", v.Slug, v.VersionNumber, f.FileName)
+	}
+
+	err = CheckFileName(f.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %v", v.Slug, v.VersionNumber, err)
+	}
+
+	name := f.FileName
+	src := f.URL
+	app := &Application{
+		Name:      &name,
+		URL:       &src,
+		Checksums: f.Hashes.Known(),
+	}
+	if f.Size > 0 {
+		size := f.Size
+		app.Size = &size
+	}
+
+	return &Artifact{Application: app}, nil
+}
+
+// Download fetches the version's primary file into dir, usually a
+// server's plugins/ directory.
+func (m *Modrinth) Download(v *ModrinthVersion, dir string, opts *DownloadOptions) error {
+	artifact, err := v.Artifact()
+	if err != nil {
+		return err
+	}
+
+	return DownloadPlugin(artifact, dir, opts)
+}
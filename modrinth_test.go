@@ -0,0 +1,156 @@
+package papertool
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// fakeModrinth serves one project with a paper version and a velocity
+// version, checking the filters it's sent the way the real API applies
+// them.
+func fakeModrinth(t *testing.T, jar []byte, sha512sum string) *url.URL {
+	paper := `{"id":"AAAA","name":"Example 2.1.0","version_number":"2.1.0","version_type":"release",` +
+		`"date_published":"2025-01-10T12:00:00.000000Z","loaders":["paper"],"game_versions":["1.21.4"],` +
+		`"files":[` +
+		`{"url":"$SERVER/sources.jar","filename":"example-2.1.0-sources.jar","primary":false,"size":1,"hashes":{"sha512":"00"}},` +
+		`{"url":"$SERVER/example.jar","filename":"example-2.1.0.jar","primary":true,"size":` + strconv.Itoa(len(jar)) + `,"hashes":{"sha512":"` + sha512sum + `","sha1":""}}` +
+		`]}`
+	velocity := `{"id":"BBBB","name":"Example 2.1.0 (Velocity)","version_number":"2.1.0-velocity","version_type":"release",` +
+		`"date_published":"2025-01-09T12:00:00.000000Z","loaders":["velocity"],"game_versions":["1.21.4"],"files":[]}`
+
+	return fakeServer(t, map[string]http.HandlerFunc{
+		"/v2/project/example/version": func(w http.ResponseWriter, r *http.Request) {
+			loaders := r.URL.Query().Get("loaders")
+			gameVersions := r.URL.Query().Get("game_versions")
+
+			switch {
+			case loaders == `["paper"]` && (gameVersions == "" || gameVersions == `["1.21.4"]`):
+				serveJSON("["+paper+"]")(w, r)
+			case loaders == `["velocity"]`:
+				serveJSON("["+velocity+"]")(w, r)
+			case loaders == "" && gameVersions == "":
+				serveJSON("["+paper+","+velocity+"]")(w, r)
+			default:
+				serveJSON("[]")(w, r)
+			}
+		},
+		"/v2/project/missing/version": serveStatus(http.StatusNotFound, `{"error":"not_found","description":"the requested route does not exist"}`),
+		"/example.jar":                serveJar(jar),
+	})
+}
+
+func TestModrinth(t *testing.T) {
+	jar := []byte("not really a plugin")
+	sum := sha512.Sum512(jar)
+	m := NewModrinth(fakeModrinth(t, jar, hex.EncodeToString(sum[:])))
+
+	all, err := m.ListVersions("example", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("unfiltered: got %d versions", len(all))
+	}
+
+	versions, err := m.ListVersions("example", ModrinthLoader(HangarPaper), "1.21.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].VersionNumber != "2.1.0" {
+		t.Fatalf("paper 1.21.4: got %+v", versions)
+	}
+	if versions[0].Published.Year() != 2025 {
+		t.Fatalf("published: got %v", versions[0].Published)
+	}
+
+	none, err := m.ListVersions("example", "paper", "1.8.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(none) != 0 {
+		t.Fatalf("paper 1.8.8: got %d versions", len(none))
+	}
+
+	_, err = m.ListVersions("missing", "paper", "")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing: got %v", err)
+	}
+
+	f, err := versions[0].PrimaryFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.FileName != "example-2.1.0.jar" {
+		t.Fatalf("primary: got %s", f.FileName)
+	}
+
+	velocity, err := m.ListVersions("example", "velocity", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = velocity[0].Artifact()
+	if err == nil {
+		t.Fatalf("velocity: expected no primary file")
+	}
+
+	// Plugin jars aren't server builds, so they mustn't be pinned or
+	// recorded as installs.
+	db, _, _ := testChecksumDB(t, PinStrict)
+	saved := KnownChecksums
+	KnownChecksums = db
+	t.Cleanup(func() { KnownChecksums = saved })
+
+	dir := t.TempDir()
+	opts := DefaultDownloadOptions()
+	opts.Progress = NopProgress{}
+
+	err = m.Download(versions[0], dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Lookup("example", "2.1.0", "AAAA") != nil {
+		t.Fatalf("checksum db: plugin was pinned")
+	}
+	_, err = os.Stat(HistoryPath(dir))
+	if !os.IsNotExist(err) {
+		t.Fatalf("history: expected none, got %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "example-2.1.0.jar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(jar) {
+		t.Fatalf("downloaded: got %q", got)
+	}
+
+	// Already there and matching, so nothing to do even without Replace.
+	err = m.Download(versions[0], dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Hashes["sha512"] = hex.EncodeToString(make([]byte, sha512.Size))
+	opts.Replace = true
+	err = m.Download(versions[0], dir, opts)
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Algorithm != "sha512" {
+		t.Fatalf("bad sha512: got %v", err)
+	}
+
+	// A file name from the server mustn't escape the plugins directory.
+	for _, name := range []string{"../../evil.jar", "/tmp/evil.jar", "..", ""} {
+		f.FileName = name
+		_, err = versions[0].Artifact()
+		if err == nil {
+			t.Fatalf("file name %q: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package papertool
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ * Vanilla server jars come from Mojang's launcher metadata:
+ *
+ *   GET https://piston-meta.mojang.com/mc/game/version_manifest_v2.json
+ *   {
+ *     "latest":   { "release": "1.21.4", "snapshot": "25w02a" },
+ *     "versions": [
+ *       {
+ *         "id":          "25w02a",
+ *         "type":        "snapshot",
+ *         "url":         "https://piston-meta.mojang.com/v1/packages/.../25w02a.json",
+ *         "time":        "2025-01-08T13:35:50+00:00",
+ *         "releaseTime": "2025-01-08T13:24:56+00:00",
+ *         "sha1":        "..."
+ *       },
+ *       ...
+ *     ]
+ *   }
+ *
+ *   GET ${url from the manifest}
+ *   {
+ *     "id":          "1.21.4",
+ *     "type":        "release",
+ *     "releaseTime": "2024-12-03T10:12:57+00:00",
+ *     "javaVersion": { "component": "java-runtime-delta", "majorVersion": 21 },
+ *     "downloads": {
+ *       "server": { "sha1": "...", "size": 57555044, "url": "https://piston-data.mojang.com/v1/objects/.../server.jar" },
+ *       ...
+ *     }
+ *   }
+ *
+ * The manifest is newest-first; it's reversed like the Fill responses.
+ * Only releases and snapshots are listed, as the old alpha and beta
+ * versions mostly have no server jar. Mojang doesn't have builds, so each
+ * version has a single build, 1. Releases are on the STABLE channel and
+ * snapshots on EXPERIMENTAL, the names Paper uses. The artifact is named
+ * vanilla-${VERSION}-1.jar so VerifyFile can place it.
+ */
+
+const Project_Vanilla = "vanilla"
+
+// VanillaBuild is the one build every vanilla version has.
+const VanillaBuild = "1"
+
+func init() {
+	RegisterProvider(Project_Vanilla, "https://piston-meta.mojang.com", func(server *url.URL) Provider {
+		return NewMojangProvider(server)
+	})
+}
+
+type mojangManifest struct {
+	Latest struct {
+		Release  string `json:"release"`
+		Snapshot string `json:"snapshot"`
+	} `json:"latest"`
+	Versions []mojangManifestVersion `json:"versions"`
+}
+
+type mojangManifestVersion struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	ReleaseTime string `json:"releaseTime"`
+	Sha1        string `json:"sha1"`
+}
+
+type mojangVersion struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	ReleaseTime string `json:"releaseTime"`
+	JavaVersion struct {
+		MajorVersion int `json:"majorVersion"`
+	} `json:"javaVersion"`
+	Downloads map[string]struct {
+		Sha1 string `json:"sha1"`
+		Size int64  `json:"size"`
+		URL  string `json:"url"`
+	} `json:"downloads"`
+}
+
+// MojangProvider fetches vanilla server jars via the version manifest. Its
+// only project is "vanilla".
+type MojangProvider struct {
+	server *url.URL
+
+	// The manifest is needed for every lookup, so it's kept for a while
+	// rather than fetched again each time.
+	mu          sync.Mutex
+	cached      *mojangManifest
+	cachedRaw   []byte
+	cachedUntil time.Time
+}
+
+// mojangManifestTTL is how long a fetched manifest is reused for. It's
+// short enough that a long running process still sees new releases.
+const mojangManifestTTL = 5 * time.Minute
+
+func NewMojangProvider(server *url.URL) *MojangProvider {
+	return &MojangProvider{
+		server: server,
+	}
+}
+
+func (m *MojangProvider) Name() string {
+	return Project_Vanilla
+}
+
+func (m *MojangProvider) checkProject(project string) error {
+	if project != Project_Vanilla {
+		return &NotFoundError{URL: m.server.String(), Message: fmt.Sprintf("unknown project '%s'", project)}
+	}
+
+	return nil
+}
+
+func (m *MojangProvider) manifest() (*mojangManifest, []byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cached != nil && time.Now().Before(m.cachedUntil) {
+		return m.cached, m.cachedRaw, nil
+	}
+
+	u := fmt.Sprintf("%s/mc/game/version_manifest_v2.json", m.server.String())
+
+	manifest := &mojangManifest{}
+	raw, err := fetch(u, manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.cached = manifest
+	m.cachedRaw = raw
+	m.cachedUntil = time.Now().Add(mojangManifestTTL)
+
+	return manifest, raw, nil
+}
+
+// version fetches the per-version JSON for id, found via the manifest.
+func (m *MojangProvider) version(id string) (*mojangVersion, []byte, error) {
+	manifest, _, err := m.manifest()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, mv := range manifest.Versions {
+		if mv.ID != id {
+			continue
+		}
+
+		v := &mojangVersion{}
+		raw, err := fetch(mv.URL, v)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return v, raw, nil
+	}
+
+	return nil, nil, &NotFoundError{URL: m.server.String(), Message: fmt.Sprintf("unknown version '%s'", id)}
+}
+
+func (m *MojangProvider) ListProjects() (*Projects, error) {
+	versions, err := m.ListVersions(Project_Vanilla)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Projects{
+		Projects: []*Project{
+			{
+				ID:            Project_Vanilla,
+				Name:          String(versions.ProjectName),
+				VersionGroups: versions.VersionGroups,
+				Versions:      versions.Versions,
+			},
+		},
+		raw: versions.raw,
+	}, nil
+}
+
+func (m *MojangProvider) ListVersions(project string) (*Versions, error) {
+	err := m.checkProject(project)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, raw, err := m.manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	pid := Project_Vanilla
+	pname := "Minecraft"
+	versions := &Versions{
+		ProjectID:     &pid,
+		ProjectName:   &pname,
+		VersionGroups: []string{"release", "snapshot"},
+		raw:           raw,
+	}
+
+	for i := len(manifest.Versions) - 1; i >= 0; i-- {
+		mv := manifest.Versions[i]
+		if mv.Type == "release" || mv.Type == "snapshot" {
+			versions.Versions = append(versions.Versions, mv.ID)
+		}
+	}
+
+	return versions, nil
+}
+
+func (m *MojangProvider) ListBuilds(project string, version string) (*Builds, error) {
+	b, err := m.GetBuild(project, version, VanillaBuild)
+	if err != nil {
+		return nil, err
+	}
+
+	pid := Project_Vanilla
+	ver := version
+
+	return &Builds{
+		ProjectID: &pid,
+		Version:   &ver,
+		Builds:    []*Build{b},
+		raw:       b.raw,
+	}, nil
+}
+
+func (m *MojangProvider) GetBuild(project string, version string, build string) (*Build, error) {
+	err := m.checkProject(project)
+	if err != nil {
+		return nil, err
+	}
+
+	if build != VanillaBuild && build != "latest" {
+		return nil, &NotFoundError{URL: m.server.String(), Message: fmt.Sprintf("vanilla %s has no build '%s', only %s", version, build, VanillaBuild)}
+	}
+
+	mv, raw, err := m.version(version)
+	if err != nil {
+		return nil, err
+	}
+
+	id := 1.0
+	pid := Project_Vanilla
+	t := mv.ReleaseTime
+	ch := mojangChannel(mv.Type)
+	b := &Build{
+		ProjectID: &pid,
+		Build:     &id,
+		Time:      &t,
+		Channel:   &ch,
+		raw:       raw,
+	}
+
+	// A release time that won't parse isn't worth losing the jar over;
+	// the raw string is still in Time.
+	b.Timestamp, _ = ParseTime(t)
+
+	server, ok := mv.Downloads["server"]
+	if !ok {
+		// Some old versions have no server jar.
+		return b, nil
+	}
+
+	name := fmt.Sprintf("%s-%s-%s.jar", Project_Vanilla, version, VanillaBuild)
+	size := server.Size
+	src := server.URL
+	b.Artifact = &Artifact{
+		Application: &Application{
+			Name:      &name,
+			Checksums: Checksums{"sha1": server.Sha1},
+			Size:      &size,
+			URL:       &src,
+		},
+	}
+
+	return b, nil
+}
+
+// mojangChannel maps a Mojang version type onto a channel name.
+func mojangChannel(typ string) string {
+	switch typ {
+	case "release":
+		return "STABLE"
+	case "snapshot":
+		return "EXPERIMENTAL"
+	case "old_beta":
+		return "BETA"
+	case "old_alpha":
+		return "ALPHA"
+	}
+
+	return strings.ToUpper(typ)
+}
+
+// GetVersion implements VersionDetailer. Mojang publishes the Java
+// version each release needs but nothing about support.
+func (m *MojangProvider) GetVersion(project string, version string) (*Version, error) {
+	err := m.checkProject(project)
+	if err != nil {
+		return nil, err
+	}
+
+	mv, raw, err := m.version(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Version{
+		ProjectID:   project,
+		ID:          mv.ID,
+		JavaMinimum: mv.JavaVersion.MajorVersion,
+		Builds:      []int{1},
+		raw:         raw,
+	}, nil
+}
+
+func (m *MojangProvider) ArtifactURL(project string, version string, build string, artifact *Artifact) (string, error) {
+	if artifact.Application.URL == nil || *artifact.Application.URL == "" {
+		return "", fmt.Errorf("vanilla %s: no server download", version)
+	}
+
+	return *artifact.Application.URL, nil
+}
+
+func (m *MojangProvider) ArtifactChecksums(project string, version string, build string, artifact *Artifact) (Checksums, error) {
+	return artifact.Application.ExpectedChecksums(), nil
+}
@@ -0,0 +1,173 @@
+package papertool
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeMojang serves a version manifest with a release, a snapshot and an
+// old beta, and the per-version JSON for the first two.
+func fakeMojang(t *testing.T, jar []byte) *url.URL {
+	sum := sha1.Sum(jar)
+	sha := hex.EncodeToString(sum[:])
+
+	return fakeServer(t, map[string]http.HandlerFunc{
+		"/mc/game/version_manifest_v2.json": serveJSON(`{"latest":{"release":"1.21.4","snapshot":"25w02a"},"versions":[` +
+			`{"id":"25w02a","type":"snapshot","url":"$SERVER/v1/packages/25w02a.json","releaseTime":"2025-01-08T13:24:56+00:00"},` +
+			`{"id":"1.21.4","type":"release","url":"$SERVER/v1/packages/1.21.4.json","releaseTime":"2024-12-03T10:12:57+00:00"},` +
+			`{"id":"b1.7.3","type":"old_beta","url":"$SERVER/v1/packages/b1.7.3.json","releaseTime":"2011-07-07T22:00:00+00:00"}]}`),
+		"/v1/packages/1.21.4.json": serveJSON(`{"id":"1.21.4","type":"release","releaseTime":"2024-12-03T10:12:57+00:00",` +
+			`"javaVersion":{"component":"java-runtime-delta","majorVersion":21},` +
+			`"downloads":{"server":{"sha1":"` + sha + `","size":` + strconv.Itoa(len(jar)) + `,"url":"$SERVER/v1/objects/server.jar"}}}`),
+		"/v1/packages/25w02a.json": serveJSON(`{"id":"25w02a","type":"snapshot","releaseTime":"2025-01-08T13:24:56+00:00",` +
+			`"javaVersion":{"majorVersion":21},"downloads":{"client":{"sha1":"x","size":1,"url":"$SERVER/nope"}}}`),
+		"/v1/objects/server.jar": serveJar(jar),
+	})
+}
+
+func TestMojangProvider(t *testing.T) {
+	jar := []byte("not really a server jar")
+	server := fakeMojang(t, jar)
+	p := NewMojangProvider(server)
+
+	versions, err := p.ListVersions(Project_Vanilla)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != 2 || versions.Versions[0] != "1.21.4" || versions.Versions[1] != "25w02a" {
+		t.Fatalf("versions: got %q", versions.Versions)
+	}
+
+	builds, err := p.ListBuilds(Project_Vanilla, "1.21.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds.Builds) != 1 || String(builds.Builds[0].Build) != VanillaBuild {
+		t.Fatalf("builds: got %d", len(builds.Builds))
+	}
+	if len(builds.InChannel("stable").Builds) != 1 {
+		t.Fatalf("release: got channel %s", String(builds.Builds[0].Channel))
+	}
+
+	b, err := p.GetBuild(Project_Vanilla, "25w02a", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if String(b.Channel) != "EXPERIMENTAL" {
+		t.Fatalf("snapshot: got channel %s", String(b.Channel))
+	}
+	if b.Artifact != nil {
+		t.Fatalf("snapshot: expected no server jar")
+	}
+
+	_, err = p.GetBuild(Project_Vanilla, "1.21.4", "2")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("bad build: got %v", err)
+	}
+	_, err = p.GetBuild(Project_Vanilla, "1.99", VanillaBuild)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("bad version: got %v", err)
+	}
+
+	v, err := p.GetVersion(Project_Vanilla, "1.21.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.JavaMinimum != 21 {
+		t.Fatalf("java: got %d", v.JavaMinimum)
+	}
+
+	artifact, err := ResolveArtifact(p, Project_Vanilla, "1.21.4", builds.Builds[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	opts := DefaultDownloadOptions()
+	opts.Progress = NopProgress{}
+
+	err = DownloadOpts(server, Project_Vanilla, "1.21.4", VanillaBuild, artifact, dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "vanilla-1.21.4-1.jar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(jar) {
+		t.Fatalf("downloaded: got %q", got)
+	}
+}
+
+func TestMojangChannel(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want string
+	}{
+		{"release", "STABLE"},
+		{"snapshot", "EXPERIMENTAL"},
+		{"old_beta", "BETA"},
+		{"old_alpha", "ALPHA"},
+		{"pending", "PENDING"},
+	}
+
+	for _, test := range tests {
+		got := mojangChannel(test.typ)
+		if got != test.want {
+			t.Fatalf("%s: expected %s got %s", test.typ, test.want, got)
+		}
+	}
+}
+
+func TestMojangManifestCachedAndBadTime(t *testing.T) {
+	manifests := 0
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/mc/game/version_manifest_v2.json": func(w http.ResponseWriter, r *http.Request) {
+			manifests++
+			serveJSON(`{"versions":[{"id":"1.21.4","type":"release","url":"$SERVER/v1/packages/1.21.4.json","releaseTime":"sometime"}]}`)(w, r)
+		},
+		"/v1/packages/1.21.4.json": serveJSON(`{"id":"1.21.4","type":"release","releaseTime":"sometime",` +
+			`"javaVersion":{"majorVersion":21},"downloads":{"server":{"sha1":"x","size":1,"url":"$SERVER/v1/objects/server.jar"}}}`),
+	})
+	p := NewMojangProvider(server)
+
+	// A release time that won't parse leaves the timestamp zero.
+	b, err := p.GetBuild(Project_Vanilla, "1.21.4", VanillaBuild)
+	if err != nil {
+		t.Fatalf("1: %v", err)
+	}
+	if !b.Timestamp.IsZero() || String(b.Time) != "sometime" || b.Artifact == nil {
+		t.Fatalf("2: got %v %s %v", b.Timestamp, String(b.Time), b.Artifact)
+	}
+
+	_, err = p.ListVersions(Project_Vanilla)
+	if err != nil {
+		t.Fatalf("3: %v", err)
+	}
+	_, err = p.GetVersion(Project_Vanilla, "1.21.4")
+	if err != nil {
+		t.Fatalf("4: %v", err)
+	}
+	if manifests != 1 {
+		t.Fatalf("5: manifest fetched %d times", manifests)
+	}
+
+	// Once it's stale it's fetched again.
+	p.cachedUntil = time.Now().Add(-time.Second)
+	_, err = p.ListVersions(Project_Vanilla)
+	if err != nil {
+		t.Fatalf("6: %v", err)
+	}
+	if manifests != 2 {
+		t.Fatalf("7: manifest fetched %d times", manifests)
+	}
+}
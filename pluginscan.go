@@ -0,0 +1,418 @@
+package papertool
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+ * Bukkit style plugins describe themselves in plugin.yml at the root of
+ * the jar:
+ *
+ *   name: Example
+ *   version: 1.2.3
+ *   main: com.example.Example
+ *   api-version: '1.21'
+ *   depend: [Vault]
+ *   softdepend: [PlaceholderAPI]
+ *
+ * Paper plugins use paper-plugin.yml instead, which has the same name,
+ * version and api-version keys but declares dependencies by load phase:
+ *
+ *   dependencies:
+ *     server:
+ *       Vault: { load: BEFORE, required: true }
+ *
+ * api-version is the oldest Minecraft version the plugin was built
+ * against; the server refuses to load plugins declaring a newer one than
+ * it runs. Plugins without one are loaded in legacy mode.
+ */
+
+// PluginInfo is what a plugin jar says about itself.
+type PluginInfo struct {
+	Path       string
+	Descriptor string // plugin.yml or paper-plugin.yml
+	Name       string
+	Version    string
+	Main       string
+	APIVersion string
+	Depend     []string // required dependencies
+	SoftDepend []string // optional dependencies
+}
+
+type pluginYML struct {
+	Name       string   `yaml:"name"`
+	Version    string   `yaml:"version"`
+	Main       string   `yaml:"main"`
+	APIVersion string   `yaml:"api-version"`
+	Depend     []string `yaml:"depend"`
+	SoftDepend []string `yaml:"softdepend"`
+
+	Dependencies struct {
+		Bootstrap map[string]paperPluginDependency `yaml:"bootstrap"`
+		Server    map[string]paperPluginDependency `yaml:"server"`
+	} `yaml:"dependencies"`
+}
+
+type paperPluginDependency struct {
+	Required *bool `yaml:"required"` // defaults to true
+}
+
+var ErrNotPlugin = errors.New("no plugin.yml or paper-plugin.yml")
+
+// ReadPluginJar reads the plugin descriptor from the jar at path,
+// preferring paper-plugin.yml if there's both.
+func ReadPluginJar(path string) (*PluginInfo, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	defer zr.Close()
+
+	for _, descriptor := range []string{"paper-plugin.yml", "plugin.yml"} {
+		f, err := zr.Open(descriptor)
+		if err != nil {
+			continue
+		}
+		raw, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", path, descriptor, err)
+		}
+
+		info, err := parsePluginYML(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %v", path, descriptor, err)
+		}
+		info.Path = path
+		info.Descriptor = descriptor
+
+		return info, nil
+	}
+
+	return nil, fmt.Errorf("%s: %w", path, ErrNotPlugin)
+}
+
+func parsePluginYML(raw []byte) (*PluginInfo, error) {
+	y := &pluginYML{}
+	err := yaml.Unmarshal(raw, y)
+	if err != nil {
+		return nil, err
+	}
+
+	if y.Name == "" {
+		return nil, fmt.Errorf("no name")
+	}
+
+	info := &PluginInfo{
+		Name:       y.Name,
+		Version:    y.Version,
+		Main:       y.Main,
+		APIVersion: y.APIVersion,
+		Depend:     y.Depend,
+		SoftDepend: y.SoftDepend,
+	}
+
+	for _, deps := range []map[string]paperPluginDependency{y.Dependencies.Bootstrap, y.Dependencies.Server} {
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			required := deps[name].Required
+			if required == nil || *required {
+				info.Depend = append(info.Depend, name)
+			} else {
+				info.SoftDepend = append(info.SoftDepend, name)
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// ScanPlugins reads every .jar directly inside dir. Jars that can't be
+// read are returned as errors alongside the plugins that could.
+func ScanPlugins(dir string) ([]*PluginInfo, []error, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.jar"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plugins := []*PluginInfo{}
+	errs := []error{}
+	for _, path := range matches {
+		info, err := ReadPluginJar(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		plugins = append(plugins, info)
+	}
+
+	return plugins, errs, nil
+}
+
+// InstallPlugin downloads a plugin jar into dir like DownloadPlugin, and
+// then looks for other jars in dir carrying the same plugin, e.g. the
+// previous version under its old file name. Left in place the server
+// would find the plugin twice, so they're removed if opts.Replace is set
+// and returned. Otherwise it's an error, and the new jar is removed again
+// unless it was already there.
+func InstallPlugin(artifact *Artifact, dir string, opts *DownloadOptions) ([]string, error) {
+	if artifact == nil || artifact.Application == nil || artifact.Application.Name == nil {
+		return nil, fmt.Errorf("bad artifact")
+	}
+
+	path := filepath.Join(dir, filepath.Base(*artifact.Application.Name))
+	_, err := os.Stat(path)
+	existed := err == nil
+
+	err = DownloadPlugin(artifact, dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := ReadPluginJar(path)
+	if err != nil {
+		// Without a name there's nothing to match other jars on.
+		return nil, nil
+	}
+
+	plugins, _, err := ScanPlugins(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	old := []string{}
+	for _, p := range plugins {
+		if p.Path != path && p.Name == info.Name {
+			old = append(old, p.Path)
+		}
+	}
+
+	if len(old) == 0 {
+		return nil, nil
+	}
+
+	if !opts.Replace {
+		if !existed {
+			os.Remove(path)
+		}
+		return nil, fmt.Errorf("%s is already installed as %s; use -replace to update it", info.Name, strings.Join(old, ", "))
+	}
+
+	for _, o := range old {
+		err := os.Remove(o)
+		if err != nil {
+			return nil, fmt.Errorf("remove %s: %v", o, err)
+		}
+	}
+
+	return old, nil
+}
+
+// CompareMinecraftVersions compares dotted numeric versions like 1.21 and
+// 1.21.4, returning -1, 0 or 1. A missing component counts as 0, so 1.21
+// and 1.21.0 are equal. A pre-release suffix such as -rc1 or -pre2 sorts
+// before the release it leads up to, and pre-releases of the same version
+// are ordered by their suffix, numerically, so -pre9 comes before -pre10.
+// ok is false if either isn't numeric.
+func CompareMinecraftVersions(a string, b string) (int, bool) {
+	pa, sa, ok := parseDotted(a)
+	if !ok {
+		return 0, false
+	}
+	pb, sb, ok := parseDotted(b)
+	if !ok {
+		return 0, false
+	}
+
+	for len(pa) < len(pb) {
+		pa = append(pa, 0)
+	}
+	for len(pb) < len(pa) {
+		pb = append(pb, 0)
+	}
+
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1, true
+		case pa[i] > pb[i]:
+			return 1, true
+		}
+	}
+
+	switch {
+	case sa == sb:
+		return 0, true
+	case sa == "":
+		return 1, true
+	case sb == "":
+		return -1, true
+	}
+
+	return compareSuffix(sa, sb), true
+}
+
+// compareSuffix orders pre-release suffixes like rc1 and pre10 by their
+// letters and then by the number after them, so pre10 comes after pre9.
+// Suffixes that aren't letters followed by a number compare as strings.
+func compareSuffix(a string, b string) int {
+	pa, na, oka := splitSuffix(a)
+	pb, nb, okb := splitSuffix(b)
+
+	switch {
+	case !oka || !okb || pa != pb:
+		return strings.Compare(a, b)
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	}
+
+	return 0
+}
+
+// splitSuffix splits s into its leading letters and the number after
+// them. A suffix without a number counts as 0.
+func splitSuffix(s string) (string, int, bool) {
+	i := strings.IndexAny(s, "0123456789")
+	if i < 0 {
+		return s, 0, true
+	}
+
+	n, err := strconv.Atoi(s[i:])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return s[:i], n, true
+}
+
+// parseDotted splits s into its numeric components and any pre-release
+// suffix after a '-'.
+func parseDotted(s string) ([]int, string, bool) {
+	s, suffix, _ := strings.Cut(s, "-")
+	if s == "" {
+		return nil, "", false
+	}
+
+	parts := []int{}
+	for _, p := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, "", false
+		}
+		parts = append(parts, n)
+	}
+
+	return parts, suffix, true
+}
+
+// PluginReport is the result of checking one plugin against a target
+// Minecraft version.
+type PluginReport struct {
+	Plugin *PluginInfo
+
+	// Problems are reasons the plugin won't load, or won't work, on the
+	// target version.
+	Problems []string
+
+	// Warnings are things worth knowing that won't stop it loading.
+	Warnings []string
+
+	// Latest is the newest Hangar version of the plugin for the target,
+	// "" if it isn't on Hangar or Hangar wasn't asked.
+	Latest string
+
+	// LatestErr is why Latest couldn't be found, if it wasn't simply
+	// because the plugin isn't on Hangar.
+	LatestErr error
+}
+
+// UpdateAvailable reports whether Hangar has a different version to the
+// installed one. Versions that parse as dotted numbers must be newer.
+func (r *PluginReport) UpdateAvailable() bool {
+	if r.Latest == "" || r.Latest == r.Plugin.Version {
+		return false
+	}
+
+	c, ok := CompareMinecraftVersions(strings.TrimPrefix(r.Latest, "v"), strings.TrimPrefix(r.Plugin.Version, "v"))
+	if ok {
+		return c > 0
+	}
+
+	return true
+}
+
+// Compatible reports whether the check found no problems.
+func (r *PluginReport) Compatible() bool {
+	return len(r.Problems) == 0
+}
+
+// CheckPlugins checks every plugin in plugins against the target
+// Minecraft version: that its api-version isn't newer than target and
+// that its required dependencies are present. If hangar is non-nil each
+// plugin is also looked up there, by name, for newer versions supporting
+// platform and target.
+func CheckPlugins(plugins []*PluginInfo, target string, hangar *Hangar, platform string) []*PluginReport {
+	installed := map[string]bool{}
+	for _, p := range plugins {
+		installed[strings.ToLower(p.Name)] = true
+	}
+
+	reports := []*PluginReport{}
+	for _, p := range plugins {
+		r := &PluginReport{
+			Plugin: p,
+		}
+
+		switch {
+		case p.APIVersion == "":
+			r.Warnings = append(r.Warnings, "no api-version, will be loaded as a legacy plugin")
+		default:
+			c, ok := CompareMinecraftVersions(p.APIVersion, target)
+			switch {
+			case !ok:
+				r.Warnings = append(r.Warnings, fmt.Sprintf("can't compare api-version %s with %s", p.APIVersion, target))
+			case c > 0:
+				r.Problems = append(r.Problems, fmt.Sprintf("api-version %s is newer than %s", p.APIVersion, target))
+			}
+		}
+
+		for _, dep := range p.Depend {
+			if !installed[strings.ToLower(dep)] {
+				r.Problems = append(r.Problems, fmt.Sprintf("missing required dependency %s", dep))
+			}
+		}
+
+		if hangar != nil {
+			versions, err := hangar.ListVersions(p.Name, platform, target, 1)
+			switch {
+			case errors.Is(err, ErrNotFound):
+			case err != nil:
+				r.LatestErr = err
+			case len(versions) == 0:
+				r.Warnings = append(r.Warnings, fmt.Sprintf("no Hangar version supports %s %s", platform, target))
+			default:
+				r.Latest = versions[0].Name
+			}
+		}
+
+		reports = append(reports, r)
+	}
+
+	return reports
+}
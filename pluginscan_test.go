@@ -0,0 +1,269 @@
+package papertool
+
+import (
+	"archive/zip"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareMinecraftVersions(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+		ok   bool
+	}{
+		{"1.20", "1.20.4", -1, true},
+		{"1.20.4", "1.20", 1, true},
+		{"1.21", "1.21.0", 0, true},
+		{"1.21", "1.21", 0, true},
+		{"1.9", "1.10", -1, true},
+		{"1.21-rc1", "1.21", -1, true},
+		{"1.21", "1.21-rc1", 1, true},
+		{"1.21-pre1", "1.21-rc1", -1, true},
+		{"1.21-rc1", "1.20.6", 1, true},
+		{"1.21-rc1", "1.21-rc1", 0, true},
+		{"1.21-pre10", "1.21-pre9", 1, true},
+		{"1.21-pre9", "1.21-pre10", -1, true},
+		{"1.21-rc1", "1.21-pre10", 1, true},
+		{"5.2.1-b100", "5.2.1-b99", 1, true},
+		{"5.2.1-b99", "5.2.1-b100", -1, true},
+		{"1.21-pre", "1.21-pre1", -1, true},
+		{"25w02a", "1.21", 0, false},
+		{"1.21", "", 0, false},
+		{"1.x", "1.21", 0, false},
+	}
+
+	for _, test := range tests {
+		got, ok := CompareMinecraftVersions(test.a, test.b)
+		if got != test.want || ok != test.ok {
+			t.Fatalf("%q vs %q: expected %d %v got %d %v", test.a, test.b, test.want, test.ok, got, ok)
+		}
+	}
+}
+
+func TestParsePluginYML(t *testing.T) {
+	tests := []struct {
+		name string
+		yml  string
+		want *PluginInfo
+	}{
+		{
+			"plugin.yml",
+			"name: Example\nversion: 1.2.3\nmain: com.example.Example\napi-version: 1.20\ndepend: [Vault]\nsoftdepend: [PlaceholderAPI]\n",
+			&PluginInfo{Name: "Example", Version: "1.2.3", Main: "com.example.Example", APIVersion: "1.20", Depend: []string{"Vault"}, SoftDepend: []string{"PlaceholderAPI"}},
+		},
+		{
+			"paper-plugin.yml",
+			"name: Example\nversion: '2.0'\nmain: com.example.Example\napi-version: '1.21'\n" +
+				"dependencies:\n  bootstrap:\n    Core: { load: BEFORE }\n  server:\n    Vault: { load: BEFORE, required: true }\n    LuckPerms: { load: BEFORE, required: false }\n",
+			&PluginInfo{Name: "Example", Version: "2.0", Main: "com.example.Example", APIVersion: "1.21", Depend: []string{"Core", "Vault"}, SoftDepend: []string{"LuckPerms"}},
+		},
+		{
+			"missing api-version",
+			"name: Legacy\nversion: 0.1\nmain: com.example.Legacy\n",
+			&PluginInfo{Name: "Legacy", Version: "0.1", Main: "com.example.Legacy"},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := parsePluginYML([]byte(test.yml))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Fatalf("%s: expected %+v got %+v", test.name, test.want, got)
+		}
+	}
+
+	for _, bad := range []string{"version: 1.0\n", "name: [unterminated\n"} {
+		_, err := parsePluginYML([]byte(bad))
+		if err == nil {
+			t.Fatalf("%q: expected error", bad)
+		}
+	}
+}
+
+// writeJar writes a jar at dir/name holding files.
+func writeJar(t *testing.T, dir string, name string, files map[string]string) string {
+	path := filepath.Join(dir, name)
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for fname, content := range files {
+		w, err := zw.Create(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = w.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestScanPlugins(t *testing.T) {
+	dir := t.TempDir()
+
+	writeJar(t, dir, "both.jar", map[string]string{
+		"plugin.yml":       "name: Both\nversion: 1.0\napi-version: '1.20'\n",
+		"paper-plugin.yml": "name: Both\nversion: 1.0\napi-version: '1.21'\n",
+	})
+	writeJar(t, dir, "bukkit.jar", map[string]string{
+		"plugin.yml": "name: Bukkit\nversion: 1.0\n",
+	})
+	writeJar(t, dir, "library.jar", map[string]string{
+		"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\n",
+	})
+	err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a jar"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, errs, err := ScanPlugins(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 2 {
+		t.Fatalf("1: expected 2 plugins, got %d", len(plugins))
+	}
+	if plugins[0].Name != "Both" || plugins[0].Descriptor != "paper-plugin.yml" || plugins[0].APIVersion != "1.21" {
+		t.Fatalf("2: paper-plugin.yml not preferred: %+v", plugins[0])
+	}
+	if plugins[1].Descriptor != "plugin.yml" || plugins[1].Path != filepath.Join(dir, "bukkit.jar") {
+		t.Fatalf("3: bad plugin %+v", plugins[1])
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrNotPlugin) {
+		t.Fatalf("4: expected ErrNotPlugin for library.jar, got %v", errs)
+	}
+}
+
+func TestCheckPlugins(t *testing.T) {
+	plugins := []*PluginInfo{
+		{Name: "ViaVersion", Version: "5.2.0", APIVersion: "1.20"},
+		{Name: "Future", Version: "1.0", APIVersion: "1.21.5"},
+		{Name: "Legacy", Version: "1.0"},
+		{Name: "Needy", Version: "1.0", APIVersion: "1.21-rc1", Depend: []string{"viaversion", "Vault"}},
+		{Name: "Odd", Version: "1.0", APIVersion: "latest"},
+	}
+
+	h := NewHangar(fakeHangar(t, []byte("jar")))
+	reports := CheckPlugins(plugins, "1.21.4", h, HangarPaper)
+	if len(reports) != len(plugins) {
+		t.Fatalf("expected %d reports, got %d", len(plugins), len(reports))
+	}
+
+	tests := []struct {
+		compatible bool
+		problem    string
+		warning    string
+		latest     string
+		update     bool
+	}{
+		{true, "", "", "5.2.1", true},
+		{false, "api-version 1.21.5 is newer than 1.21.4", "", "", false},
+		{true, "", "no api-version", "", false},
+		{false, "missing required dependency Vault", "", "", false},
+		{true, "", "can't compare api-version latest", "", false},
+	}
+
+	for i, test := range tests {
+		r := reports[i]
+		name := r.Plugin.Name
+		if r.Compatible() != test.compatible {
+			t.Fatalf("%s: expected compatible %v, problems %q", name, test.compatible, r.Problems)
+		}
+		if test.problem != "" && (len(r.Problems) != 1 || r.Problems[0] != test.problem) {
+			t.Fatalf("%s: expected problem %q got %q", name, test.problem, r.Problems)
+		}
+		if test.warning != "" && (len(r.Warnings) != 1 || !strings.HasPrefix(r.Warnings[0], test.warning)) {
+			t.Fatalf("%s: expected warning %q got %q", name, test.warning, r.Warnings)
+		}
+		if r.Latest != test.latest || r.UpdateAvailable() != test.update {
+			t.Fatalf("%s: expected latest %q update %v got %q %v (%v)", name, test.latest, test.update, r.Latest, r.UpdateAvailable(), r.LatestErr)
+		}
+	}
+}
+
+func TestInstallPlugin(t *testing.T) {
+	src := writeJar(t, t.TempDir(), "new.jar", map[string]string{
+		"plugin.yml": "name: ViaVersion\nversion: 5.2.1\n",
+	})
+	jar, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/jar/": serveJar(jar),
+	})
+
+	dir := t.TempDir()
+	old := writeJar(t, dir, "ViaVersion-5.2.0.jar", map[string]string{
+		"plugin.yml": "name: ViaVersion\nversion: 5.2.0\n",
+	})
+	other := writeJar(t, dir, "ViaBackwards-5.2.0.jar", map[string]string{
+		"plugin.yml": "name: ViaBackwards\nversion: 5.2.0\n",
+	})
+	path := filepath.Join(dir, "ViaVersion-5.2.1.jar")
+
+	opts := &DownloadOptions{Progress: NopProgress{}}
+
+	// The old version is still there, so without Replace it's refused and
+	// nothing is left behind.
+	_, err = InstallPlugin(jarArtifact(server.String(), "ViaVersion-5.2.1.jar", jar, ""), dir, opts)
+	if err == nil || !strings.Contains(err.Error(), old) {
+		t.Fatalf("1: expected already installed error got %v", err)
+	}
+	_, err = os.Stat(path)
+	if !os.IsNotExist(err) {
+		t.Fatalf("2: new jar left behind: %v", err)
+	}
+
+	opts.Replace = true
+	replaced, err := InstallPlugin(jarArtifact(server.String(), "ViaVersion-5.2.1.jar", jar, ""), dir, opts)
+	if err != nil {
+		t.Fatalf("3: %v", err)
+	}
+	if !reflect.DeepEqual(replaced, []string{old}) {
+		t.Fatalf("4: replaced %q", replaced)
+	}
+
+	for _, test := range []struct {
+		path   string
+		exists bool
+	}{
+		{old, false},
+		{other, true},
+		{path, true},
+	} {
+		_, err = os.Stat(test.path)
+		if (err == nil) != test.exists {
+			t.Fatalf("5: %s: expected exists %v got %v", test.path, test.exists, err)
+		}
+	}
+
+	// Installing it again is a no-op.
+	opts.Replace = false
+	replaced, err = InstallPlugin(jarArtifact(server.String(), "ViaVersion-5.2.1.jar", jar, ""), dir, opts)
+	if err != nil || len(replaced) != 0 {
+		t.Fatalf("6: got %q %v", replaced, err)
+	}
+}
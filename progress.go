@@ -0,0 +1,430 @@
+package papertool
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress is a snapshot of the state of a single download.
+type Progress struct {
+	Name    string
+	Bytes   int64
+	Total   int64         // expected size in bytes, 0 if unknown
+	Rate    float64       // bytes per second
+	ETA     time.Duration // 0 if Total is unknown
+	Elapsed time.Duration
+}
+
+// ProgressReporter receives download lifecycle events. A single reporter
+// may be shared by concurrent downloads, so implementations must be safe
+// for concurrent use and should key their state on Progress.Name.
+type ProgressReporter interface {
+	Started(p *Progress)
+	Progress(p *Progress)
+	Finished(p *Progress, sha256 string)
+	Failed(p *Progress, err error)
+
+	// Skipped is called instead of the other events for a download that
+	// didn't need to happen, e.g. because the file is already current.
+	Skipped(p *Progress, reason string)
+}
+
+// AutoProgress picks a reporter for f: redrawn progress bars if f is a
+// terminal, plain log lines otherwise. If quiet is set, only the final
+// line for each download is printed.
+func AutoProgress(f *os.File, quiet bool) ProgressReporter {
+	if IsTerminal(f) {
+		return NewTTYProgress(f, quiet)
+	}
+
+	return NewLogProgress(f, quiet)
+}
+
+// IsTerminal reports whether f is connected to a terminal.
+func IsTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return st.Mode()&os.ModeCharDevice != 0
+}
+
+// --- No-op. ---
+
+type NopProgress struct{}
+
+func (NopProgress) Started(p *Progress)                 {}
+func (NopProgress) Progress(p *Progress)                {}
+func (NopProgress) Finished(p *Progress, sha256 string) {}
+func (NopProgress) Failed(p *Progress, err error)       {}
+func (NopProgress) Skipped(p *Progress, reason string)  {}
+
+// --- Shared formatting. ---
+
+type progressFormatter struct {
+	p      *message.Printer
+	format number.FormatFunc
+}
+
+func newProgressFormatter() *progressFormatter {
+	return &progressFormatter{
+		p:      message.NewPrinter(language.English),
+		format: number.NewFormat(number.Decimal, number.MaxFractionDigits(2), number.MinFractionDigits(2)),
+	}
+}
+
+func (f *progressFormatter) progress(p *Progress) string {
+	kb := float64(p.Bytes) / 1000.0
+	kbps := p.Rate / 1000.0
+
+	if p.Total <= 0 {
+		return f.p.Sprintf("Downloading %s %v KB (%v KB/s)", p.Name, f.format(kb), f.format(kbps))
+	}
+
+	pct := 100.0 * float64(p.Bytes) / float64(p.Total)
+	return f.p.Sprintf("Downloading %s %v KB (%v%%, %v KB/s, ETA %s)", p.Name, f.format(kb), f.format(pct), f.format(kbps), FormatETA(p.ETA))
+}
+
+func (f *progressFormatter) finished(p *Progress, sha256 string) string {
+	return f.p.Sprintf("Downloaded %s %v bytes (%v KB/s) sha256 %s", p.Name, number.Decimal(p.Bytes), f.format(p.Rate/1000.0), sha256)
+}
+
+func (f *progressFormatter) failed(p *Progress, err error) string {
+	return f.p.Sprintf("Failed %s: %v", p.Name, err)
+}
+
+func (f *progressFormatter) skipped(p *Progress, reason string) string {
+	return f.p.Sprintf("Skipped %s: %s", p.Name, reason)
+}
+
+// FormatETA renders d as m:ss or h:mm:ss.
+func FormatETA(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d / time.Hour)
+	m := int(d/time.Minute) % 60
+	s := int(d/time.Second) % 60
+
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// --- Terminal. ---
+
+// TTYProgress renders one line per active download, redrawn in place with
+// ANSI escapes. Downloads with a known size get a progress bar.
+type TTYProgress struct {
+	mu    sync.Mutex
+	out   io.Writer
+	quiet bool
+	f     *progressFormatter
+	slots map[string]int
+	lines []string
+	final []bool
+	drawn int
+}
+
+const ttyBarWidth = 30
+
+func NewTTYProgress(out io.Writer, quiet bool) *TTYProgress {
+	return &TTYProgress{
+		out:   out,
+		quiet: quiet,
+		f:     newProgressFormatter(),
+		slots: map[string]int{},
+	}
+}
+
+func (t *TTYProgress) Started(p *Progress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.slots[p.Name] = len(t.lines)
+	t.lines = append(t.lines, "Starting "+p.Name)
+	t.final = append(t.final, false)
+
+	if !t.quiet {
+		t.redraw()
+	}
+}
+
+func (t *TTYProgress) Progress(p *Progress) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	line := t.f.progress(p)
+	if p.Total > 0 {
+		line = bar(p.Bytes, p.Total, ttyBarWidth) + " " + line
+	}
+	t.set(p.Name, line, false)
+}
+
+func (t *TTYProgress) Finished(p *Progress, sha256 string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.set(p.Name, t.f.finished(p, sha256), true)
+}
+
+func (t *TTYProgress) Failed(p *Progress, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.set(p.Name, t.f.failed(p, err), true)
+}
+
+func (t *TTYProgress) Skipped(p *Progress, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.slots[p.Name]; !ok {
+		t.slots[p.Name] = len(t.lines)
+		t.lines = append(t.lines, "")
+		t.final = append(t.final, false)
+	}
+
+	t.set(p.Name, t.f.skipped(p, reason), true)
+}
+
+func (t *TTYProgress) set(name string, line string, final bool) {
+	slot, ok := t.slots[name]
+	if !ok {
+		return
+	}
+
+	t.lines[slot] = line
+	t.final[slot] = final
+
+	if t.quiet {
+		if final {
+			fmt.Fprintf(t.out, "%s\n", line)
+		}
+	} else {
+		t.redraw()
+	}
+
+	// Once everything on screen is finished, let it scroll away so the
+	// next batch of downloads starts a fresh block.
+	for _, f := range t.final {
+		if !f {
+			return
+		}
+	}
+	t.slots = map[string]int{}
+	t.lines = nil
+	t.final = nil
+	t.drawn = 0
+}
+
+func (t *TTYProgress) redraw() {
+	if t.drawn > 0 {
+		io.WriteString(t.out, SOL+CursorUp(t.drawn))
+	}
+
+	for _, line := range t.lines {
+		fmt.Fprintf(t.out, "%s%s%s\n", EraseLine, SOL, line)
+	}
+	t.drawn = len(t.lines)
+}
+
+// CursorUp moves the cursor up n lines.
+func CursorUp(n int) string {
+	return fmt.Sprintf("%s%dA", Esc, n)
+}
+
+func bar(n int64, total int64, width int) string {
+	filled := int(int64(width) * n / total)
+	if filled > width {
+		filled = width
+	}
+
+	if filled == width {
+		return "[" + strings.Repeat("=", width) + "]"
+	}
+
+	return "[" + strings.Repeat("=", filled) + ">" + strings.Repeat(" ", width-filled-1) + "]"
+}
+
+// --- Plain log lines. ---
+
+// LogProgress writes a plain line per download at most once per Interval,
+// suitable for logs and journald where escape codes are just noise.
+type LogProgress struct {
+	Interval time.Duration
+
+	mu    sync.Mutex
+	out   io.Writer
+	quiet bool
+	f     *progressFormatter
+	last  map[string]time.Time
+}
+
+func NewLogProgress(out io.Writer, quiet bool) *LogProgress {
+	return &LogProgress{
+		Interval: 5 * time.Second,
+		out:      out,
+		quiet:    quiet,
+		f:        newProgressFormatter(),
+		last:     map[string]time.Time{},
+	}
+}
+
+func (l *LogProgress) Started(p *Progress) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.last[p.Name] = time.Now()
+	if !l.quiet {
+		fmt.Fprintf(l.out, "Starting %s\n", p.Name)
+	}
+}
+
+func (l *LogProgress) Progress(p *Progress) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.quiet || time.Since(l.last[p.Name]) < l.Interval {
+		return
+	}
+	l.last[p.Name] = time.Now()
+
+	fmt.Fprintf(l.out, "%s\n", l.f.progress(p))
+}
+
+func (l *LogProgress) Finished(p *Progress, sha256 string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.last, p.Name)
+	fmt.Fprintf(l.out, "%s\n", l.f.finished(p, sha256))
+}
+
+func (l *LogProgress) Failed(p *Progress, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.last, p.Name)
+	fmt.Fprintf(l.out, "%s\n", l.f.failed(p, err))
+}
+
+func (l *LogProgress) Skipped(p *Progress, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.last, p.Name)
+	fmt.Fprintf(l.out, "%s\n", l.f.skipped(p, reason))
+}
+
+// --- JSON lines. ---
+
+// JSONProgress writes one JSON object per event, one per line. Progress
+// events are rate limited to one per Interval per download.
+type JSONProgress struct {
+	Interval time.Duration
+
+	mu   sync.Mutex
+	enc  *json.Encoder
+	last map[string]time.Time
+}
+
+type ProgressEvent struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Name    string    `json:"name"`
+	Bytes   int64     `json:"bytes"`
+	Total   int64     `json:"total,omitempty"`
+	Rate    float64   `json:"rate"`
+	ETA     float64   `json:"eta_seconds,omitempty"`
+	Elapsed float64   `json:"elapsed_seconds"`
+	Sha256  string    `json:"sha256,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Reason  string    `json:"reason,omitempty"`
+}
+
+func NewJSONProgress(out io.Writer) *JSONProgress {
+	return &JSONProgress{
+		Interval: time.Second,
+		enc:      json.NewEncoder(out),
+		last:     map[string]time.Time{},
+	}
+}
+
+func (j *JSONProgress) Started(p *Progress) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.last[p.Name] = time.Now()
+	j.emit("started", p, "", nil)
+}
+
+func (j *JSONProgress) Progress(p *Progress) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if time.Since(j.last[p.Name]) < j.Interval {
+		return
+	}
+	j.last[p.Name] = time.Now()
+
+	j.emit("progress", p, "", nil)
+}
+
+func (j *JSONProgress) Finished(p *Progress, sha256 string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delete(j.last, p.Name)
+	j.emit("finished", p, sha256, nil)
+}
+
+func (j *JSONProgress) Failed(p *Progress, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delete(j.last, p.Name)
+	j.emit("failed", p, "", err)
+}
+
+func (j *JSONProgress) Skipped(p *Progress, reason string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delete(j.last, p.Name)
+	ev := j.event("skipped", p)
+	ev.Reason = reason
+	j.enc.Encode(ev)
+}
+
+func (j *JSONProgress) emit(event string, p *Progress, sha256 string, err error) {
+	ev := j.event(event, p)
+	ev.Sha256 = sha256
+	if err != nil {
+		ev.Error = err.Error()
+	}
+
+	j.enc.Encode(ev)
+}
+
+func (j *JSONProgress) event(event string, p *Progress) *ProgressEvent {
+	return &ProgressEvent{
+		Time:    time.Now().UTC(),
+		Event:   event,
+		Name:    p.Name,
+		Bytes:   p.Bytes,
+		Total:   p.Total,
+		Rate:    p.Rate,
+		ETA:     p.ETA.Seconds(),
+		Elapsed: p.Elapsed.Seconds(),
+	}
+}
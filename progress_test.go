@@ -0,0 +1,184 @@
+package papertool
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJSONProgress(t *testing.T) {
+	out := &bytes.Buffer{}
+	j := NewJSONProgress(out)
+	j.Interval = 0
+
+	p := &Progress{Name: "a.jar", Bytes: 10, Total: 20, Rate: 5, ETA: 2 * time.Second, Elapsed: 2 * time.Second}
+	j.Started(p)
+	j.Progress(p)
+	j.Finished(p, "abc")
+	j.Failed(&Progress{Name: "b.jar"}, fmt.Errorf("boom"))
+	j.Skipped(&Progress{Name: "c.jar"}, "already up to date")
+
+	events := []*ProgressEvent{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		ev := &ProgressEvent{}
+		err := json.Unmarshal(scanner.Bytes(), ev)
+		if err != nil {
+			t.Fatalf("1: line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, ev)
+	}
+
+	tests := []struct {
+		event  string
+		name   string
+		sha256 string
+		err    string
+		reason string
+	}{
+		{"started", "a.jar", "", "", ""},
+		{"progress", "a.jar", "", "", ""},
+		{"finished", "a.jar", "abc", "", ""},
+		{"failed", "b.jar", "", "boom", ""},
+		{"skipped", "c.jar", "", "", "already up to date"},
+	}
+
+	if len(events) != len(tests) {
+		t.Fatalf("2: expected %d events got %d", len(tests), len(events))
+	}
+
+	for i, test := range tests {
+		ev := events[i]
+		if ev.Event != test.event || ev.Name != test.name || ev.Sha256 != test.sha256 || ev.Error != test.err || ev.Reason != test.reason {
+			t.Fatalf("3: event %d: got %+v", i, ev)
+		}
+	}
+
+	if events[1].Bytes != 10 || events[1].Total != 20 || events[1].ETA != 2 || events[1].Elapsed != 2 {
+		t.Fatalf("4: bad progress event %+v", events[1])
+	}
+}
+
+func TestJSONProgressConcurrent(t *testing.T) {
+	out := &bytes.Buffer{}
+	j := NewJSONProgress(out)
+	j.Interval = 0
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			p := &Progress{Name: fmt.Sprintf("%d.jar", i)}
+			j.Started(p)
+			for n := 0; n < 10; n++ {
+				j.Progress(p)
+			}
+			j.Finished(p, "abc")
+		}(i)
+	}
+	wg.Wait()
+
+	// Shared between downloads, every line must still be one whole object.
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 8*12 {
+		t.Fatalf("1: expected %d lines got %d", 8*12, len(lines))
+	}
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("2: bad line %q", line)
+		}
+	}
+}
+
+func TestJSONProgressThrottle(t *testing.T) {
+	out := &bytes.Buffer{}
+	j := NewJSONProgress(out)
+	j.Interval = time.Hour
+
+	p := &Progress{Name: "a.jar"}
+	j.Started(p)
+	for i := 0; i < 10; i++ {
+		j.Progress(p)
+	}
+	j.Finished(p, "abc")
+
+	if strings.Count(out.String(), `"event":"progress"`) != 0 || strings.Count(out.String(), "\n") != 2 {
+		t.Fatalf("1: progress not throttled:\n%s", out.String())
+	}
+}
+
+func TestLogProgressThrottle(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := NewLogProgress(out, false)
+	l.Interval = time.Hour
+
+	a := &Progress{Name: "a.jar", Bytes: 1000}
+	b := &Progress{Name: "b.jar", Bytes: 1000}
+	l.Started(a)
+	l.Started(b)
+	for i := 0; i < 10; i++ {
+		l.Progress(a)
+		l.Progress(b)
+	}
+
+	if strings.Count(out.String(), "Downloading") != 0 {
+		t.Fatalf("1: progress not throttled:\n%s", out.String())
+	}
+
+	// Each download is throttled on its own.
+	l.Interval = 0
+	l.Progress(a)
+	l.Interval = time.Hour
+	l.Progress(a)
+	l.Progress(b)
+	if strings.Count(out.String(), "Downloading a.jar") != 1 || strings.Count(out.String(), "Downloading b.jar") != 0 {
+		t.Fatalf("2: bad progress lines:\n%s", out.String())
+	}
+
+	l.Finished(a, "abc")
+	l.Skipped(b, "already up to date")
+	if !strings.Contains(out.String(), "Downloaded a.jar") || !strings.Contains(out.String(), "Skipped b.jar: already up to date") {
+		t.Fatalf("3: missing final lines:\n%s", out.String())
+	}
+}
+
+func TestLogProgressQuiet(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := NewLogProgress(out, true)
+	l.Interval = 0
+
+	p := &Progress{Name: "a.jar"}
+	l.Started(p)
+	l.Progress(p)
+	l.Failed(p, fmt.Errorf("boom"))
+
+	if out.String() != "Failed a.jar: boom\n" {
+		t.Fatalf("1: expected only the final line got %q", out.String())
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{0, "0:00"},
+		{1500 * time.Millisecond, "0:02"},
+		{61 * time.Second, "1:01"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03"},
+	}
+
+	for _, test := range tests {
+		got := FormatETA(test.d)
+		if got != test.expected {
+			t.Fatalf("1: %v: expected %q got %q", test.d, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package papertool
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// Provider is an upstream source of server builds. The PaperMC Fill API is
+// the default; other distributions register their own with
+// RegisterProvider. Providers translate into the same Versions / Builds /
+// Build types, so everything downstream of them is shared.
+type Provider interface {
+	Name() string
+	ListProjects() (*Projects, error)
+	ListVersions(project string) (*Versions, error)
+	ListBuilds(project string, version string) (*Builds, error)
+
+	// GetBuild returns a single build, always with its Artifact filled in.
+	// ListBuilds may leave Artifact nil if the upstream's listing doesn't
+	// include it.
+	GetBuild(project string, version string, build string) (*Build, error)
+
+	// ArtifactURL returns where to download an artifact from one of this
+	// provider's builds.
+	ArtifactURL(project string, version string, build string, artifact *Artifact) (string, error)
+
+	// ArtifactChecksums returns the checksums to verify the artifact
+	// against.
+	ArtifactChecksums(project string, version string, build string, artifact *Artifact) (Checksums, error)
+}
+
+// VersionDetailer is implemented by providers that can report per-version
+// support status and Java requirements.
+type VersionDetailer interface {
+	GetVersion(project string, version string) (*Version, error)
+}
+
+// ProviderFactory creates a provider talking to server.
+type ProviderFactory func(server *url.URL) Provider
+
+type registeredProvider struct {
+	factory       ProviderFactory
+	defaultServer string
+}
+
+var (
+	providersMu sync.Mutex
+	providers   = map[string]*registeredProvider{}
+)
+
+const DefaultProvider = "fill"
+
+func init() {
+	RegisterProvider(DefaultProvider, "https://fill.papermc.io", func(server *url.URL) Provider {
+		return NewFillProvider(server)
+	})
+}
+
+// RegisterProvider makes a provider available to NewProvider under name.
+func RegisterProvider(name string, defaultServer string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	providers[name] = &registeredProvider{
+		factory:       factory,
+		defaultServer: defaultServer,
+	}
+}
+
+// NewProvider returns the named provider. If server is nil the provider's
+// default server is used.
+func NewProvider(name string, server *url.URL) (Provider, error) {
+	providersMu.Lock()
+	rp, ok := providers[name]
+	providersMu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown provider '%s' (known providers: %v)", name, ProviderNames())
+	}
+
+	if server == nil {
+		var err error
+		server, err = url.Parse(rp.defaultServer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rp.factory(server), nil
+}
+
+// ProviderNames returns the names of every registered provider, sorted.
+func ProviderNames() []string {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ProviderForProject returns the provider to use for project when none
+// was asked for: a provider registered under the project's own name (e.g.
+// "purpur"), otherwise DefaultProvider.
+func ProviderForProject(project string) string {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if _, ok := providers[project]; ok {
+		return project
+	}
+
+	return DefaultProvider
+}
+
+// ProviderServing returns the provider to look project up with: p if it's
+// the provider for project, otherwise the provider for project on its
+// default server.
+func ProviderServing(p Provider, project string) (Provider, error) {
+	name := ProviderForProject(project)
+	if p != nil && p.Name() == name {
+		return p, nil
+	}
+
+	return NewProvider(name, nil)
+}
+
+// ProviderDefaultServer returns the server a provider talks to by default,
+// or "" if there's no such provider.
+func ProviderDefaultServer(name string) string {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	rp, ok := providers[name]
+	if !ok {
+		return ""
+	}
+
+	return rp.defaultServer
+}
+
+// ResolveArtifact returns a copy of b's artifact with its download URL and
+// checksums filled in by p, fetching the build first if the listing it
+// came from didn't include the artifact.
+func ResolveArtifact(p Provider, project string, version string, b *Build) (*Artifact, error) {
+	build := String(b.Build)
+
+	if b.Artifact == nil || b.Artifact.Application == nil {
+		full, err := p.GetBuild(project, version, build)
+		if err != nil {
+			return nil, err
+		}
+		b = full
+	}
+
+	if b.Artifact == nil || b.Artifact.Application == nil {
+		return nil, fmt.Errorf("%s %s build %s: no artifact", project, version, build)
+	}
+
+	src, err := p.ArtifactURL(project, version, build, b.Artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := p.ArtifactChecksums(project, version, build, b.Artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	app := *b.Artifact.Application
+	app.URL = &src
+	app.Checksums = sums
+	if sha, ok := sums["sha256"]; ok {
+		app.Sha256 = &sha
+	}
+
+	return &Artifact{Application: &app}, nil
+}
+
+// LatestBuild returns the newest build in builds that has an artifact,
+// fetching builds whose listing entry leaves the artifact out. Builds
+// that failed (Purpur keeps those in its listing) have no artifact and are
+// skipped.
+func LatestBuild(p Provider, project string, version string, builds *Builds) (*Build, error) {
+	for i := len(builds.Builds) - 1; i >= 0; i-- {
+		b := builds.Builds[i]
+		if b.Artifact == nil || b.Artifact.Application == nil {
+			full, err := p.GetBuild(project, version, String(b.Build))
+			if err != nil {
+				return nil, err
+			}
+			b = full
+		}
+
+		if b.Artifact != nil && b.Artifact.Application != nil {
+			return b, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s %s: no successful builds", project, version)
+}
+
+// --- PaperMC Fill. ---
+
+// FillProvider talks to the PaperMC Fill (v3) API via GetVersions,
+// GetBuilds and friends.
+type FillProvider struct {
+	server *url.URL
+}
+
+func NewFillProvider(server *url.URL) *FillProvider {
+	return &FillProvider{
+		server: server,
+	}
+}
+
+func (f *FillProvider) Name() string {
+	return DefaultProvider
+}
+
+func (f *FillProvider) ListProjects() (*Projects, error) {
+	return GetProjects(f.server)
+}
+
+func (f *FillProvider) ListVersions(project string) (*Versions, error) {
+	return GetVersions(f.server, project)
+}
+
+func (f *FillProvider) ListBuilds(project string, version string) (*Builds, error) {
+	return GetBuilds(f.server, project, version)
+}
+
+func (f *FillProvider) GetBuild(project string, version string, build string) (*Build, error) {
+	return GetBuild(f.server, project, version, build)
+}
+
+func (f *FillProvider) GetVersion(project string, version string) (*Version, error) {
+	return GetVersion(f.server, project, version)
+}
+
+func (f *FillProvider) ArtifactURL(project string, version string, build string, artifact *Artifact) (string, error) {
+	return artifactURL(f.server, project, version, build, artifact), nil
+}
+
+func (f *FillProvider) ArtifactChecksums(project string, version string, build string, artifact *Artifact) (Checksums, error) {
+	return artifact.Application.ExpectedChecksums(), nil
+}
@@ -0,0 +1,281 @@
+package papertool
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+/*
+ * Purpur (https://purpurmc.org) publishes its builds through its own v2
+ * style API:
+ *
+ *   GET https://api.purpurmc.org/v2/purpur
+ *   {
+ *     "project":  "purpur",
+ *     "metadata": { "current": "1.21.4" },
+ *     "versions": ["1.14.1", ..., "1.21.4"]
+ *   }
+ *
+ *   GET https://api.purpurmc.org/v2/purpur/${VERSION}
+ *   {
+ *     "project": "purpur",
+ *     "version": "1.21.4",
+ *     "builds":  { "latest": "2416", "all": ["2300", ..., "2416"] }
+ *   }
+ *
+ *   GET https://api.purpurmc.org/v2/purpur/${VERSION}/${BUILD}
+ *   {
+ *     "project":   "purpur",
+ *     "version":   "1.21.4",
+ *     "build":     "2416",
+ *     "result":    "SUCCESS",
+ *     "timestamp": 1739380034000,
+ *     "duration":  120513,
+ *     "md5":       "...",
+ *     "commits": [
+ *       { "author": "...", "email": "...", "description": "...", "hash": "...", "timestamp": 1739379000000 },
+ *       ...
+ *     ]
+ *   }
+ *
+ *   GET https://api.purpurmc.org/v2/purpur/${VERSION}/${BUILD}/download
+ *   (the jar)
+ *
+ * Both lists are oldest-first, which is already the legacy order. The
+ * build listing has no detail, so ListBuilds leaves Artifact nil and
+ * GetBuild fills it in. Purpur only publishes an md5, which goes in
+ * Checksums; Sha256 stays nil.
+ */
+
+const Project_Purpur = "purpur"
+
+func init() {
+	RegisterProvider(Project_Purpur, "https://api.purpurmc.org", func(server *url.URL) Provider {
+		return NewPurpurProvider(server)
+	})
+}
+
+type purpurProject struct {
+	Project  string `json:"project"`
+	Metadata struct {
+		Current string `json:"current"`
+	} `json:"metadata"`
+	Versions []string `json:"versions"`
+}
+
+type purpurVersion struct {
+	Project string `json:"project"`
+	Version string `json:"version"`
+	Builds  struct {
+		Latest string   `json:"latest"`
+		All    []string `json:"all"`
+	} `json:"builds"`
+}
+
+type purpurBuild struct {
+	Project   string         `json:"project"`
+	Version   string         `json:"version"`
+	Build     string         `json:"build"`
+	Result    string         `json:"result"`
+	Timestamp JsonTime       `json:"timestamp"`
+	MD5       string         `json:"md5"`
+	Commits   []purpurCommit `json:"commits"`
+}
+
+type purpurCommit struct {
+	Author      string   `json:"author"`
+	Description string   `json:"description"`
+	Hash        string   `json:"hash"`
+	Timestamp   JsonTime `json:"timestamp"`
+}
+
+// Purpur doesn't have release channels; every build it publishes is
+// treated as stable. Failed builds are weeded out by having no artifact.
+const purpurChannel = "STABLE"
+
+// PurpurProvider talks to the Purpur API. It only has the one project,
+// "purpur".
+type PurpurProvider struct {
+	server *url.URL
+}
+
+func NewPurpurProvider(server *url.URL) *PurpurProvider {
+	return &PurpurProvider{
+		server: server,
+	}
+}
+
+func (p *PurpurProvider) Name() string {
+	return Project_Purpur
+}
+
+func (p *PurpurProvider) checkProject(project string) error {
+	if project != Project_Purpur {
+		return &NotFoundError{URL: p.server.String(), Message: fmt.Sprintf("unknown project '%s'", project)}
+	}
+
+	return nil
+}
+
+func (p *PurpurProvider) ListProjects() (*Projects, error) {
+	versions, err := p.ListVersions(Project_Purpur)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Projects{
+		Projects: []*Project{
+			{
+				ID:       Project_Purpur,
+				Name:     String(versions.ProjectName),
+				Versions: versions.Versions,
+			},
+		},
+		raw: versions.raw,
+	}, nil
+}
+
+func (p *PurpurProvider) ListVersions(project string) (*Versions, error) {
+	err := p.checkProject(project)
+	if err != nil {
+		return nil, err
+	}
+
+	u := fmt.Sprintf("%s/v2/purpur", p.server.String())
+
+	pp := &purpurProject{}
+	raw, err := fetch(u, pp)
+	if err != nil {
+		return nil, err
+	}
+
+	pid := Project_Purpur
+	pname := "Purpur"
+
+	return &Versions{
+		ProjectID:   &pid,
+		ProjectName: &pname,
+		Versions:    pp.Versions,
+		raw:         raw,
+	}, nil
+}
+
+func (p *PurpurProvider) ListBuilds(project string, version string) (*Builds, error) {
+	err := p.checkProject(project)
+	if err != nil {
+		return nil, err
+	}
+
+	u := fmt.Sprintf("%s/v2/purpur/%s", p.server.String(), version)
+
+	pv := &purpurVersion{}
+	raw, err := fetch(u, pv)
+	if err != nil {
+		return nil, err
+	}
+
+	pid := Project_Purpur
+	ver := version
+	builds := &Builds{
+		ProjectID: &pid,
+		Version:   &ver,
+		raw:       raw,
+	}
+
+	for _, id := range pv.Builds.All {
+		n, err := strconv.ParseFloat(id, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse build id %q: %w", id, err)
+		}
+		ch := purpurChannel
+		builds.Builds = append(builds.Builds, &Build{
+			ProjectID: &pid,
+			Build:     &n,
+			Channel:   &ch,
+		})
+	}
+
+	return builds, nil
+}
+
+func (p *PurpurProvider) GetBuild(project string, version string, build string) (*Build, error) {
+	err := p.checkProject(project)
+	if err != nil {
+		return nil, err
+	}
+
+	u := fmt.Sprintf("%s/v2/purpur/%s/%s", p.server.String(), version, build)
+
+	pb := &purpurBuild{}
+	raw, err := fetch(u, pb)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := purpurBuildToLegacy(pb)
+	if err != nil {
+		return nil, err
+	}
+	b.raw = raw
+
+	return b, nil
+}
+
+func purpurBuildToLegacy(pb *purpurBuild) (*Build, error) {
+	id, err := strconv.ParseFloat(pb.Build, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse build id %q: %w", pb.Build, err)
+	}
+
+	pid := Project_Purpur
+	ch := purpurChannel
+	b := &Build{
+		ProjectID: &pid,
+		Build:     &id,
+		Channel:   &ch,
+		Timestamp: time.Time(pb.Timestamp),
+	}
+	if !b.Timestamp.IsZero() {
+		t := b.Timestamp.UTC().Format(time.RFC3339Nano)
+		b.Time = &t
+	}
+
+	for _, c := range pb.Commits {
+		hash := c.Hash
+		msg := c.Description
+		change := &Change{
+			Commit:    &hash,
+			Summary:   &msg,
+			Message:   &msg,
+			Timestamp: time.Time(c.Timestamp),
+		}
+		if !change.Timestamp.IsZero() {
+			ct := change.Timestamp.UTC().Format(time.RFC3339Nano)
+			change.Time = &ct
+		}
+		b.Changes = append(b.Changes, change)
+	}
+
+	// Failed builds have no jar.
+	if pb.Result == "SUCCESS" {
+		name := fmt.Sprintf("purpur-%s-%s.jar", pb.Version, pb.Build)
+		b.Artifact = &Artifact{
+			Application: &Application{
+				Name:      &name,
+				Checksums: Checksums{"md5": pb.MD5},
+			},
+		}
+	}
+
+	return b, nil
+}
+
+func (p *PurpurProvider) ArtifactURL(project string, version string, build string, artifact *Artifact) (string, error) {
+	return fmt.Sprintf("%s/v2/purpur/%s/%s/download", p.server.String(), version, build), nil
+}
+
+func (p *PurpurProvider) ArtifactChecksums(project string, version string, build string, artifact *Artifact) (Checksums, error) {
+	return artifact.Application.ExpectedChecksums(), nil
+}
@@ -0,0 +1,170 @@
+package papertool
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakePurpur serves a cut-down copy of the Purpur API with one version
+// and two builds, the second of which failed.
+func fakePurpur(t *testing.T, jar []byte) *url.URL {
+	sum := md5.Sum(jar)
+
+	return fakeServer(t, map[string]http.HandlerFunc{
+		"/v2/purpur":        serveJSON(`{"project":"purpur","metadata":{"current":"1.21.4"},"versions":["1.21.3","1.21.4"]}`),
+		"/v2/purpur/1.21.4": serveJSON(`{"project":"purpur","version":"1.21.4","builds":{"latest":"2416","all":["2415","2416"]}}`),
+		"/v2/purpur/1.21.4/2415": serveJSON(`{"project":"purpur","version":"1.21.4","build":"2415","result":"SUCCESS","timestamp":1739380034000,` +
+			`"md5":"` + hex.EncodeToString(sum[:]) + `",` +
+			`"commits":[{"author":"a","email":"e","description":"Fix things","hash":"abc123","timestamp":1739379000000}]}`),
+		"/v2/purpur/1.21.4/2416":          serveJSON(`{"project":"purpur","version":"1.21.4","build":"2416","result":"FAILURE","timestamp":1739390034000,"md5":"","commits":[]}`),
+		"/v2/purpur/1.21.4/2414":          serveJSON(`{"project":"purpur","version":"1.21.4","build":"2414","result":"SUCCESS","md5":"","commits":[]}`),
+		"/v2/purpur/1.21.4/2415/download": serveJar(jar),
+		"/v2/purpur/1.21.4/9999":          serveStatus(http.StatusNotFound, `{"error":"build not found"}`),
+	})
+}
+
+func TestPurpurProvider(t *testing.T) {
+	jar := []byte("not really a jar")
+	server := fakePurpur(t, jar)
+
+	p, err := NewProvider(ProviderForProject(Project_Purpur), server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := p.ListVersions(Project_Purpur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != 2 || versions.Versions[1] != "1.21.4" {
+		t.Fatalf("versions: got %q", versions.Versions)
+	}
+
+	builds, err := p.ListBuilds(Project_Purpur, "1.21.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds.Builds) != 2 || String(builds.FindBuild("latest").Build) != "2416" {
+		t.Fatalf("builds: got %d, latest %s", len(builds.Builds), String(builds.FindBuild("latest").Build))
+	}
+
+	b, err := p.GetBuild(Project_Purpur, "1.21.4", "2415")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Timestamp.UnixMilli() != 1739380034000 {
+		t.Fatalf("timestamp: got %v", b.Timestamp)
+	}
+	if len(b.Changes) != 1 || String(b.Changes[0].Commit) != "abc123" || String(b.Changes[0].Message) != "Fix things" {
+		t.Fatalf("changes: got %+v", b.Changes)
+	}
+	if String(b.Artifact.Application.Name) != "purpur-1.21.4-2415.jar" {
+		t.Fatalf("artifact: got %s", String(b.Artifact.Application.Name))
+	}
+
+	failed, err := p.GetBuild(Project_Purpur, "1.21.4", "2416")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ResolveArtifact(p, Project_Purpur, "1.21.4", failed)
+	if err == nil {
+		t.Fatalf("failed build: expected no artifact")
+	}
+
+	_, err = p.GetBuild(Project_Purpur, "1.21.4", "9999")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing build: got %v", err)
+	}
+
+	_, err = p.ListVersions(Project_Paper)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("wrong project: got %v", err)
+	}
+
+	// The listing has no artifact, so ResolveArtifact has to fetch it.
+	artifact, err := ResolveArtifact(p, Project_Purpur, "1.21.4", builds.FindBuild("2415"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	opts := DefaultDownloadOptions()
+	opts.Progress = NopProgress{}
+
+	err = DownloadOpts(server, Project_Purpur, "1.21.4", "2415", artifact, dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "purpur-1.21.4-2415.jar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(jar) {
+		t.Fatalf("downloaded: got %q", got)
+	}
+
+	r := VerifyFileFrom(p, filepath.Join(dir, "purpur-1.21.4-2415.jar"))
+	if r.Status != VerifyOK {
+		t.Fatalf("verify: got %s: %v", r.Status, r.Err)
+	}
+
+	// A corrupted download fails the md5 check.
+	artifact.Application.Checksums["md5"] = "00000000000000000000000000000000"
+	opts.Replace = true
+	err = DownloadOpts(server, Project_Purpur, "1.21.4", "2415", artifact, dir, opts)
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("bad md5: got %v", err)
+	}
+}
+
+// TestPurpurLatestInChannel follows what get and download do with a Purpur
+// listing: filter by channel, then pick the latest build that has a jar.
+func TestPurpurLatestInChannel(t *testing.T) {
+	server := fakePurpur(t, []byte("not really a jar"))
+	p := NewPurpurProvider(server)
+
+	builds, err := p.ListBuilds(Project_Purpur, "1.21.4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stable := builds.InChannel("stable")
+	if len(stable.Builds) != 2 {
+		t.Fatalf("1: expected 2 stable builds, got %d", len(stable.Builds))
+	}
+	if len(builds.InChannel("ALPHA").Builds) != 0 {
+		t.Fatalf("2: expected no alpha builds")
+	}
+
+	// 2416 failed, so latest is 2415.
+	latest, err := LatestBuild(p, Project_Purpur, "1.21.4", stable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if String(latest.Build) != "2415" || String(latest.Channel) != "STABLE" {
+		t.Fatalf("3: got build %s channel %s", String(latest.Build), String(latest.Channel))
+	}
+
+	// A build without a timestamp has no Time, which String and
+	// InChannel must cope with.
+	b, err := p.GetBuild(Project_Purpur, "1.21.4", "2414")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Time != nil || String(b.Time) != "<nil>" {
+		t.Fatalf("4: expected no time, got %s", String(b.Time))
+	}
+	b.Channel = nil
+	none := (&Builds{Builds: []*Build{b}}).InChannel("STABLE")
+	if len(none.Builds) != 0 {
+		t.Fatalf("5: build without a channel matched")
+	}
+}
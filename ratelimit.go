@@ -0,0 +1,91 @@
+package papertool
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting throughput in bytes per second.
+// A single RateLimiter can be shared between concurrent downloads to keep
+// their combined rate under the cap.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter allowing bytesPerSecond on average,
+// with bursts of up to a tenth of a second's worth of data.
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	burst := float64(bytesPerSecond) / 10
+	if burst < 32*1024 {
+		burst = 32 * 1024
+	}
+
+	return &RateLimiter{
+		rate:   float64(bytesPerSecond),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until n bytes may be sent. Callers take their tokens up front
+// and then sleep off any deficit outside the lock, so waiters are served
+// in the order they arrived.
+func (rl *RateLimiter) Wait(n int) {
+	rl.mu.Lock()
+
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * rl.rate
+	if rl.tokens > rl.burst {
+		rl.tokens = rl.burst
+	}
+	rl.last = now
+
+	rl.tokens -= float64(n)
+	deficit := -rl.tokens
+
+	rl.mu.Unlock()
+
+	if deficit > 0 {
+		time.Sleep(time.Duration(deficit / rl.rate * float64(time.Second)))
+	}
+}
+
+// ParseRate parses a rate such as "500K", "2M" or "1G" into bytes per
+// second. Suffixes are powers of 1024, as with curl's --limit-rate.
+func ParseRate(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty rate")
+	}
+
+	mult := int64(1)
+	switch strings.ToUpper(s[len(s)-1:]) {
+	case "K":
+		mult = 1024
+	case "M":
+		mult = 1024 * 1024
+	case "G":
+		mult = 1024 * 1024 * 1024
+	}
+	if mult != 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad rate %q: %v", s, err)
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("bad rate %q: must be positive", s)
+	}
+
+	return int64(n * float64(mult)), nil
+}
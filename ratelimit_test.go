@@ -0,0 +1,61 @@
+package papertool
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"100", 100},
+		{"2K", 2048},
+		{"2M", 2 * 1024 * 1024},
+		{"1.5m", 1536 * 1024},
+		{"1G", 1024 * 1024 * 1024},
+	}
+
+	for _, test := range tests {
+		got, err := ParseRate(test.in)
+		if err != nil {
+			t.Fatalf("%s: %v", test.in, err)
+		}
+		if got != test.want {
+			t.Fatalf("%s: expected %d got %d", test.in, test.want, got)
+		}
+	}
+
+	for _, bad := range []string{"", "M", "-1K", "0", "fast"} {
+		_, err := ParseRate(bad)
+		if err == nil {
+			t.Fatalf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestRateLimiterShared(t *testing.T) {
+	rl := NewRateLimiter(1024 * 1024)
+
+	// Two writers pushing 256KB each past the initial burst should take
+	// at least ~0.4s between them at 1MB/s.
+	start := time.Now()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 8; j++ {
+				rl.Wait(32 * 1024)
+			}
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	if elapsed < 350*time.Millisecond {
+		t.Fatalf("expected throttling, took %v", elapsed)
+	}
+}
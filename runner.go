@@ -0,0 +1,269 @@
+package papertool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Runner supervises a server process: it starts it, forwards Stdin to
+// its console, restarts it with backoff if it crashes, and restarts it on
+// request or on a schedule, running Update first so a newer build can be
+// installed.
+//
+// A clean exit (status 0), e.g. from typing "stop" at the console, ends
+// Run. Anything else is a crash.
+type Runner struct {
+	Dir string
+
+	// Command returns the command line to start the server with. It's
+	// called before every start so it picks up anything Update changed.
+	Command func() ([]string, error)
+
+	// Update, if set, is called before every planned restart. An error
+	// is logged and the server restarted as it was.
+	Update func() error
+
+	// Schedule, if set, returns when the next planned restart after now
+	// is due.
+	Schedule func(now time.Time) time.Time
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Restarts after a crash wait MinBackoff, doubling up to MaxBackoff.
+	// The wait goes back to MinBackoff once the server has stayed up for
+	// StableAfter.
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	StableAfter time.Duration
+
+	// Stopping sends StopCommand to the console and waits up to
+	// StopTimeout for the server to exit before killing it.
+	StopCommand string
+	StopTimeout time.Duration
+
+	// Logf reports what the runner is doing. Defaults to stderr.
+	Logf func(format string, args ...interface{})
+
+	restart chan struct{}
+
+	mu      sync.Mutex
+	stdin   io.WriteCloser // the running server's console
+	process *os.Process
+}
+
+// NewRunner returns a runner for the server installed in dir, started
+// with the command line from its install record.
+func NewRunner(dir string) *Runner {
+	return &Runner{
+		Dir: dir,
+		Command: func() ([]string, error) {
+			rec, err := ReadInstallRecord(dir)
+			if err != nil {
+				return nil, err
+			}
+			return rec.Command(), nil
+		},
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		MinBackoff:  time.Second,
+		MaxBackoff:  5 * time.Minute,
+		StableAfter: 10 * time.Minute,
+		StopCommand: "stop",
+		StopTimeout: 2 * time.Minute,
+		Logf: func(format string, args ...interface{}) {
+			fmt.Fprintf(os.Stderr, "papertool: "+format+"\n", args...)
+		},
+		restart: make(chan struct{}, 1),
+	}
+}
+
+// Restart asks Run for a planned restart. It doesn't wait for it.
+func (r *Runner) Restart() {
+	select {
+	case r.restart <- struct{}{}:
+	default:
+	}
+}
+
+// Run supervises the server until ctx is done, when it's stopped
+// gracefully, or until it exits cleanly by itself.
+func (r *Runner) Run(ctx context.Context) error {
+	if r.Stdin != nil {
+		go r.forwardStdin()
+	}
+
+	backoff := r.MinBackoff
+	planned := false
+
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		if planned && r.Update != nil {
+			err := r.Update()
+			if err != nil {
+				r.Logf("update failed, restarting with the current build: %v", err)
+			}
+		}
+		planned = false
+
+		argv, err := r.Command()
+		if err != nil {
+			return err
+		}
+		if len(argv) == 0 {
+			return fmt.Errorf("empty command")
+		}
+
+		started := time.Now()
+		done, err := r.start(argv)
+		if err != nil {
+			return err
+		}
+
+		var scheduled <-chan time.Time
+		if r.Schedule != nil {
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(time.Until(r.Schedule(started)))
+			scheduled = timer.C
+		}
+
+		select {
+		case err := <-done:
+			if err == nil {
+				r.Logf("server exited cleanly")
+				return nil
+			}
+
+			if time.Since(started) >= r.StableAfter {
+				backoff = r.MinBackoff
+			}
+			r.Logf("server crashed (%v), restarting in %v", err, backoff)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-r.restart:
+				planned = true
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > r.MaxBackoff {
+				backoff = r.MaxBackoff
+			}
+
+		case <-ctx.Done():
+			r.Logf("stopping server")
+			r.stop(done)
+			return nil
+
+		case <-r.restart:
+			r.Logf("restarting server")
+			r.stop(done)
+			planned = true
+
+		case <-scheduled:
+			r.Logf("scheduled restart")
+			r.stop(done)
+			planned = true
+		}
+	}
+}
+
+// start runs argv in Dir. The returned channel receives its exit status.
+func (r *Runner) start(argv []string) (<-chan error, error) {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = r.Dir
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	r.Logf("starting %v", argv)
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.stdin = stdin
+	r.process = cmd.Process
+	r.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+
+		r.mu.Lock()
+		r.stdin = nil
+		r.process = nil
+		r.mu.Unlock()
+
+		done <- err
+	}()
+
+	return done, nil
+}
+
+// stop asks the server to shut down via its console, killing it if it
+// hasn't within StopTimeout.
+func (r *Runner) stop(done <-chan error) {
+	r.mu.Lock()
+	stdin := r.stdin
+	process := r.process
+	r.mu.Unlock()
+
+	if stdin != nil && r.StopCommand != "" {
+		io.WriteString(stdin, r.StopCommand+"\n")
+	}
+
+	select {
+	case <-done:
+		return
+	case <-time.After(r.StopTimeout):
+	}
+
+	r.Logf("server didn't stop within %v, killing it", r.StopTimeout)
+	if process != nil {
+		process.Kill()
+	}
+	<-done
+}
+
+// forwardStdin copies Stdin to whichever server is running. Input while
+// the server is down is dropped.
+func (r *Runner) forwardStdin() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Stdin.Read(buf)
+		if n > 0 {
+			r.mu.Lock()
+			if r.stdin != nil {
+				r.stdin.Write(buf[:n])
+			}
+			r.mu.Unlock()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
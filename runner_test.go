@@ -0,0 +1,166 @@
+package papertool
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to write from the server's output
+// goroutine while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// testRunner returns a runner for a stand-in server: a shell script
+// rather than a jar.
+func testRunner(t *testing.T, script string) (*Runner, *syncBuffer) {
+	out := &syncBuffer{}
+
+	r := NewRunner(t.TempDir())
+	r.Command = func() ([]string, error) {
+		return []string{"sh", "-c", script}, nil
+	}
+	r.Stdin = nil
+	r.Stdout = out
+	r.Stderr = out
+	r.MinBackoff = 10 * time.Millisecond
+	r.MaxBackoff = 40 * time.Millisecond
+	r.StopTimeout = 5 * time.Second
+	r.Logf = func(format string, args ...interface{}) {}
+
+	return r, out
+}
+
+func TestRunnerRestartsAfterCrash(t *testing.T) {
+	// Crashes twice, then exits cleanly.
+	r, _ := testRunner(t, `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; [ $n -ge 3 ] && exit 0; exit 1`)
+
+	err := r.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := os.ReadFile(filepath.Join(r.Dir, "count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(count)) != "3" {
+		t.Fatalf("expected 3 starts, got %s", count)
+	}
+}
+
+func TestRunnerConsoleAndPlannedRestart(t *testing.T) {
+	// Echoes console input and exits cleanly on "stop", like a server.
+	r, out := testRunner(t, `echo started; while read line; do [ "$line" = stop ] && { echo stopping; exit 0; }; echo "got $line"; done`)
+
+	console, consoleW := io.Pipe()
+	r.Stdin = console
+
+	updates := 0
+	var mu sync.Mutex
+	r.Update = func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		updates++
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- r.Run(ctx)
+	}()
+
+	waitFor(t, "first start", func() bool { return strings.Count(out.String(), "started") == 1 })
+
+	io.WriteString(consoleW, "say hello\n")
+	waitFor(t, "console input", func() bool { return strings.Contains(out.String(), "got say hello") })
+
+	r.Restart()
+	waitFor(t, "restart", func() bool { return strings.Count(out.String(), "started") == 2 })
+
+	mu.Lock()
+	if updates != 1 {
+		t.Fatalf("expected 1 update before the restart, got %d", updates)
+	}
+	mu.Unlock()
+	if strings.Count(out.String(), "stopping") != 1 {
+		t.Fatalf("expected a graceful stop before the restart:\n%s", out.String())
+	}
+
+	cancel()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Run didn't return after cancel")
+	}
+	if strings.Count(out.String(), "stopping") != 2 {
+		t.Fatalf("expected a graceful stop on cancel:\n%s", out.String())
+	}
+
+	consoleW.Close()
+}
+
+func TestRunnerScheduledRestartKillsStuckServer(t *testing.T) {
+	// Ignores the stop command.
+	r, out := testRunner(t, `echo started; exec sleep 60`)
+	r.StopTimeout = 50 * time.Millisecond
+
+	starts := 0
+	r.Schedule = func(now time.Time) time.Time {
+		starts++
+		if starts == 1 {
+			return now.Add(50 * time.Millisecond)
+		}
+		return now.Add(time.Hour)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- r.Run(ctx)
+	}()
+
+	waitFor(t, "scheduled restart", func() bool { return strings.Count(out.String(), "started") == 2 })
+
+	cancel()
+	err := <-result
+	if err != nil {
+		t.Fatal(err)
+	}
+}
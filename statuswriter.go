@@ -1,11 +1,7 @@
 package papertool
 
 import (
-	"crypto/sha256"
-	"golang.org/x/text/language"
-	"golang.org/x/text/message"
-	"golang.org/x/text/number"
-	"hash"
+	"fmt"
 	"os"
 	"time"
 )
@@ -16,44 +12,131 @@ const (
 	SOL       = "\r"
 )
 
+// StatusWriter sits at the end of the download stream, hashing what goes
+// past with every known checksum algorithm and passing progress along to a
+// ProgressReporter.
 type StatusWriter struct {
-	p      *message.Printer
-	format number.FormatFunc
-	last   int64
-	total  int64
-	start  time.Time
-	name   string
-	quiet  bool
-	sha256 hash.Hash
+	reporter ProgressReporter
+	last     int64
+	total    int64
+	expected int64
+	start    time.Time
+	name     string
+	hashes   multiHash
+	limiter  *RateLimiter
 }
 
+// NewStatusWriter returns a StatusWriter printing progress to stdout,
+// unless quiet.
 func NewStatusWriter(name string, quiet bool) *StatusWriter {
-	return &StatusWriter{
-		p:      message.NewPrinter(language.English),
-		format: number.NewFormat(number.Decimal, number.MaxFractionDigits(2), number.MinFractionDigits(2)),
-		last:   0,
-		total:  0,
-		start:  time.Now(),
-		name:   name,
-		quiet:  quiet,
-		sha256: sha256.New(),
+	return NewStatusWriterOpts(name, &StatusWriterOptions{
+		Reporter: AutoProgress(os.Stdout, quiet),
+	})
+}
+
+type StatusWriterOptions struct {
+	// Expected, if > 0, is the size of the download. It's used for
+	// percent complete and ETA, and writes past it fail.
+	Expected int64
+
+	// Reporter receives progress events. Defaults to NopProgress.
+	Reporter ProgressReporter
+
+	// RateLimit, if set, throttles writes.
+	RateLimit *RateLimiter
+}
+
+func NewStatusWriterOpts(name string, opts *StatusWriterOptions) *StatusWriter {
+	reporter := opts.Reporter
+	if reporter == nil {
+		reporter = NopProgress{}
+	}
+
+	sw := &StatusWriter{
+		reporter: reporter,
+		last:     0,
+		total:    0,
+		expected: opts.Expected,
+		start:    time.Now(),
+		name:     name,
+		hashes:   newMultiHash(),
+		limiter:  opts.RateLimit,
 	}
+
+	reporter.Started(sw.Progress())
+
+	return sw
 }
 
 func (sw *StatusWriter) Write(data []byte) (int, error) {
+	if sw.expected > 0 && sw.total+int64(len(data)) > sw.expected {
+		return 0, fmt.Errorf("received more than the advertised %d bytes", sw.expected)
+	}
+
+	// Holding up the write holds up the reader feeding us, which is what
+	// throttles the stream.
+	if sw.limiter != nil {
+		sw.limiter.Wait(len(data))
+	}
+
 	sw.total += int64(len(data))
-	sw.sha256.Write(data)
+	sw.hashes.Write(data)
 
-	if !sw.quiet {
-		if sw.total-sw.last >= 256*1000 {
-			kb := float64(sw.total) / 1000.0
-			elapsed := time.Now().Sub(sw.start)
-			kbps := kb / elapsed.Seconds()
-			sw.p.Fprintf(os.Stdout, "%s%sDownloading %s %v KB (%v KB/s)", EraseLine, SOL, sw.name, sw.format(kb), sw.format(kbps))
-			os.Stdout.Sync()
-			sw.last = sw.total
-		}
+	if sw.total-sw.last >= 256*1000 {
+		sw.reporter.Progress(sw.Progress())
+		sw.last = sw.total
 	}
 
 	return len(data), nil
 }
+
+// Progress returns a snapshot of the download so far.
+func (sw *StatusWriter) Progress() *Progress {
+	elapsed := time.Now().Sub(sw.start)
+
+	p := &Progress{
+		Name:    sw.name,
+		Bytes:   sw.total,
+		Total:   sw.expected,
+		Elapsed: elapsed,
+	}
+
+	if elapsed > 0 {
+		p.Rate = float64(sw.total) / elapsed.Seconds()
+	}
+
+	if p.Total > 0 && p.Rate > 0 && p.Total > p.Bytes {
+		p.ETA = time.Duration(float64(p.Total-p.Bytes) / p.Rate * float64(time.Second))
+	}
+
+	return p
+}
+
+// Sha256 returns the hex encoded sha256 of everything written so far.
+func (sw *StatusWriter) Sha256() string {
+	return hexSum(sw.hashes["sha256"])
+}
+
+// Checksums returns every known checksum of everything written so far.
+func (sw *StatusWriter) Checksums() Checksums {
+	return sw.hashes.Sums()
+}
+
+// Check returns an error if the download ended short of the expected size.
+func (sw *StatusWriter) Check() error {
+	if sw.expected > 0 && sw.total != sw.expected {
+		return fmt.Errorf("received %d bytes, expected %d", sw.total, sw.expected)
+	}
+
+	return nil
+}
+
+// Finish reports the download as complete.
+func (sw *StatusWriter) Finish() {
+	sw.reporter.Finished(sw.Progress(), sw.Sha256())
+}
+
+// Fail reports the download as failed.
+func (sw *StatusWriter) Fail(err error) {
+	sw.reporter.Failed(sw.Progress(), err)
+}
@@ -0,0 +1,160 @@
+package papertool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStatusWriterSize(t *testing.T) {
+	sw := NewStatusWriterOpts("test", &StatusWriterOptions{Expected: 4})
+
+	_, err := sw.Write([]byte("abc"))
+	if err != nil {
+		t.Fatalf("1: %v", err)
+	}
+	if sw.Check() == nil {
+		t.Fatalf("2: short body passed the check")
+	}
+
+	_, err = sw.Write([]byte("de"))
+	if err == nil {
+		t.Fatalf("3: write past the expected size succeeded")
+	}
+
+	_, err = sw.Write([]byte("d"))
+	if err != nil {
+		t.Fatalf("4: %v", err)
+	}
+	if err := sw.Check(); err != nil {
+		t.Fatalf("5: %v", err)
+	}
+
+	// No size means no checks.
+	sw = NewStatusWriter("test", true)
+	_, err = sw.Write([]byte("abc"))
+	if err != nil || sw.Check() != nil {
+		t.Fatalf("6: unexpected size check")
+	}
+}
+
+// jarArtifact returns an artifact for /jar/name on server carrying every
+// known checksum of jar, with the one named by wrong zeroed out.
+func jarArtifact(server string, name string, jar []byte, wrong string) *Artifact {
+	sums := newMultiHash()
+	sums.Write(jar)
+	checksums := sums.Sums()
+	if wrong != "" {
+		checksums[wrong] = strings.Repeat("0", len(checksums[wrong]))
+	}
+
+	src := server + "/jar/" + name
+	size := int64(len(jar))
+
+	return &Artifact{
+		Application: &Application{Name: &name, URL: &src, Size: &size, Checksums: checksums},
+	}
+}
+
+func TestDownloadShortBody(t *testing.T) {
+	jar := []byte("not really a jar")
+
+	mu := &sync.Mutex{}
+	methods := []string{}
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/jar/": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			methods = append(methods, r.Method)
+			mu.Unlock()
+
+			// Flushing first sends it chunked, without a
+			// Content-Length, so only the byte count can catch it.
+			w.(http.Flusher).Flush()
+			w.Write(jar)
+		},
+	})
+
+	artifact := jarArtifact(server.String(), "short.jar", jar, "")
+	size := int64(len(jar) + 10)
+	artifact.Application.Size = &size
+
+	opts := &DownloadOptions{Progress: NopProgress{}}
+	err := DownloadOpts(server, "paper", "1.21.4", "1", artifact, t.TempDir(), opts)
+	if err == nil || !strings.Contains(err.Error(), "expected") {
+		t.Fatalf("1: expected a short body error got %v", err)
+	}
+
+	err = DownloadTo(context.Background(), artifact, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "expected") {
+		t.Fatalf("2: expected a short body error got %v", err)
+	}
+
+	// The size is checked on the GET, without a separate HEAD.
+	for _, method := range methods {
+		if method != http.MethodGet {
+			t.Fatalf("3: unexpected %s request", method)
+		}
+	}
+}
+
+func TestDownloadLongBody(t *testing.T) {
+	jar := []byte("not really a jar")
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/jar/": serveJar(jar),
+	})
+
+	artifact := jarArtifact(server.String(), "long.jar", jar, "")
+	size := int64(len(jar) - 1)
+	artifact.Application.Size = &size
+
+	opts := &DownloadOptions{Progress: NopProgress{}}
+	err := DownloadOpts(server, "paper", "1.21.4", "1", artifact, t.TempDir(), opts)
+	if err == nil {
+		t.Fatalf("1: overlong body downloaded")
+	}
+
+	// DownloadTo catches it from the GET's Content-Length.
+	w := &bytes.Buffer{}
+	err = DownloadTo(context.Background(), artifact, w)
+	if err == nil || !strings.Contains(err.Error(), "Content-Length") {
+		t.Fatalf("2: expected a Content-Length error got %v", err)
+	}
+	if w.Len() != 0 {
+		t.Fatalf("3: %d bytes written despite the bad Content-Length", w.Len())
+	}
+}
+
+func TestDownloadWrongChecksum(t *testing.T) {
+	jar := []byte("not really a jar")
+	server := fakeServer(t, map[string]http.HandlerFunc{
+		"/jar/": serveJar(jar),
+	})
+
+	for _, alg := range KnownChecksumAlgorithms() {
+		artifact := jarArtifact(server.String(), alg+".jar", jar, alg)
+
+		opts := &DownloadOptions{Progress: NopProgress{}}
+		err := DownloadOpts(server, "paper", "1.21.4", "1", artifact, t.TempDir(), opts)
+
+		var mismatch *ChecksumMismatchError
+		if !errors.As(err, &mismatch) || mismatch.Algorithm != alg {
+			t.Fatalf("1: %s: expected a mismatch got %v", alg, err)
+		}
+
+		err = DownloadTo(context.Background(), artifact, &bytes.Buffer{})
+		if !errors.As(err, &mismatch) || mismatch.Algorithm != alg {
+			t.Fatalf("2: %s: expected a mismatch got %v", alg, err)
+		}
+	}
+
+	// And all of them right downloads fine.
+	artifact := jarArtifact(server.String(), "good.jar", jar, "")
+	err := DownloadOpts(server, "paper", "1.21.4", "1", artifact, t.TempDir(), &DownloadOptions{Progress: NopProgress{}})
+	if err != nil {
+		t.Fatalf("3: %v", err)
+	}
+}
@@ -17,6 +17,9 @@ func String(v interface{}) string {
 
 	rv := reflect.ValueOf(v)
 	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "<nil>"
+		}
 		return String(rv.Elem().Interface())
 	}
 
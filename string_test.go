@@ -25,4 +25,9 @@ func TestString(t *testing.T) {
 	if f2 != "12345.6" {
 		t.Fatalf("4: expected 12345.6 got '%s'", f2)
 	}
+
+	var np *string
+	if String(np) != "<nil>" {
+		t.Fatalf("5: expected <nil> got '%s'", String(np))
+	}
 }
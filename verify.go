@@ -0,0 +1,157 @@
+package papertool
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type VerifyStatus string
+
+const (
+	VerifyOK       VerifyStatus = "OK"
+	VerifyMismatch VerifyStatus = "MISMATCH"
+	VerifyUnknown  VerifyStatus = "UNKNOWN"
+)
+
+// VerifyResult is the outcome of checking one file against the checksum
+// published for the build it claims to be.
+type VerifyResult struct {
+	Path     string
+	Project  string
+	Version  string
+	Build    string
+	Sha256   string
+	Expected string // published sha256, if there is one
+	Status   VerifyStatus
+	Err      error // why the status is MISMATCH or UNKNOWN
+}
+
+// ParseArtifactName splits an artifact file name such as
+// "paper-1.21.4-232.jar" or "velocity-3.5.0-SNAPSHOT-594.jar" into its
+// project, version and build number.
+func ParseArtifactName(name string) (project string, version string, build string, ok bool) {
+	base := strings.TrimSuffix(filepath.Base(name), ".jar")
+	if base == filepath.Base(name) {
+		return "", "", "", false
+	}
+
+	first := strings.Index(base, "-")
+	last := strings.LastIndex(base, "-")
+	if first <= 0 || last <= first+1 || last == len(base)-1 {
+		return "", "", "", false
+	}
+
+	build = base[last+1:]
+	_, err := strconv.Atoi(build)
+	if err != nil {
+		return "", "", "", false
+	}
+
+	return base[:first], base[first+1 : last], build, true
+}
+
+// HashFile returns the hex encoded sha256 of the file at path.
+func HashFile(path string) (string, error) {
+	sums, err := HashFileChecksums(path)
+	if err != nil {
+		return "", err
+	}
+
+	return sums["sha256"], nil
+}
+
+// VerifyFile hashes the file at path, works out which build it is from
+// its name, and compares it against the checksum the server publishes for
+// that build.
+func VerifyFile(serverURL *url.URL, path string) *VerifyResult {
+	return VerifyFileFrom(NewFillProvider(serverURL), path)
+}
+
+// VerifyFileFrom is VerifyFile against any provider. If the file belongs
+// to a project p doesn't serve, it's checked against that project's own
+// provider instead (see ProviderServing).
+func VerifyFileFrom(p Provider, path string) *VerifyResult {
+	result := &VerifyResult{
+		Path:   path,
+		Status: VerifyUnknown,
+	}
+
+	actual, err := HashFileChecksums(path)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Sha256 = actual["sha256"]
+
+	project, version, build, ok := ParseArtifactName(path)
+	if !ok {
+		result.Err = fmt.Errorf("can't tell which build this is from its name")
+		return result
+	}
+	result.Project = project
+	result.Version = version
+	result.Build = build
+
+	p, err = ProviderServing(p, project)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	b, err := p.GetBuild(project, version, build)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if b.Artifact == nil || b.Artifact.Application == nil {
+		result.Err = fmt.Errorf("no published checksum")
+		return result
+	}
+
+	expected, err := p.ArtifactChecksums(project, version, build, b.Artifact)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if len(expected.Known()) == 0 {
+		result.Err = fmt.Errorf("no published checksum")
+		return result
+	}
+	result.Expected = expected["sha256"]
+
+	err = expected.Verify(path, actual)
+	if err != nil {
+		result.Status = VerifyMismatch
+		result.Err = err
+		return result
+	}
+
+	result.Status = VerifyOK
+
+	return result
+}
+
+// VerifyDir runs VerifyFile on every .jar directly inside dir.
+func VerifyDir(serverURL *url.URL, dir string) ([]*VerifyResult, error) {
+	return VerifyDirFrom(NewFillProvider(serverURL), dir)
+}
+
+// VerifyDirFrom is VerifyDir against any provider, choosing the provider
+// for each file as VerifyFileFrom does.
+func VerifyDirFrom(p Provider, dir string) ([]*VerifyResult, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.jar"))
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*VerifyResult{}
+	for _, path := range matches {
+		results = append(results, VerifyFileFrom(p, path))
+	}
+
+	return results, nil
+}
@@ -0,0 +1,87 @@
+package papertool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseArtifactName(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		version string
+		build   string
+	}{
+		{"paper-1.21.4-232.jar", "paper", "1.21.4", "232"},
+		{"velocity-3.5.0-SNAPSHOT-594.jar", "velocity", "3.5.0-SNAPSHOT", "594"},
+		{"/srv/mc/waterfall-1.21-600.jar", "waterfall", "1.21", "600"},
+	}
+
+	for _, test := range tests {
+		project, version, build, ok := ParseArtifactName(test.name)
+		if !ok {
+			t.Fatalf("%s: expected ok", test.name)
+		}
+		if project != test.project || version != test.version || build != test.build {
+			t.Fatalf("%s: got %s %s %s", test.name, project, version, build)
+		}
+	}
+
+	for _, bad := range []string{"server.jar", "paper-1.21.4.jar", "paper-1.21.4-latest.jar", "paper-1.21.4-232.zip", "-1.21-5.jar"} {
+		_, _, _, ok := ParseArtifactName(bad)
+		if ok {
+			t.Fatalf("%s: expected failure", bad)
+		}
+	}
+}
+
+func TestVerifyDirFromProviderPerFile(t *testing.T) {
+	jar := []byte("not really a jar")
+	sum := sha256.Sum256(jar)
+	sha := hex.EncodeToString(sum[:])
+
+	build := `{"id": 1, "time": "2026-05-01T18:17:18Z", "channel": "STABLE", "downloads": {"server:default": {"name": "%s", "checksums": {"sha256": "` + sha + `"}}}}`
+	paper := fakeServer(t, map[string]http.HandlerFunc{
+		"/v3/projects/paper/versions/1.21.4/builds/1": serveJSON(fmt.Sprintf(build, "paper-1.21.4-1.jar")),
+	})
+	other := fakeServer(t, map[string]http.HandlerFunc{
+		"/v3/projects/verifytest/versions/1.0/builds/7": serveJSON(fmt.Sprintf(build, "verifytest-1.0-7.jar")),
+	})
+
+	// A provider of its own for the verifytest project, on another server.
+	RegisterProvider("verifytest", other.String(), func(server *url.URL) Provider {
+		return NewFillProvider(server)
+	})
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, "verifytest")
+		providersMu.Unlock()
+	})
+
+	dir := t.TempDir()
+	for _, name := range []string{"paper-1.21.4-1.jar", "verifytest-1.0-7.jar"} {
+		err := os.WriteFile(filepath.Join(dir, name), jar, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := VerifyDirFrom(NewFillProvider(paper), dir)
+	if err != nil {
+		t.Fatalf("1: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("2: expected 2 results got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Status != VerifyOK {
+			t.Fatalf("3: %s: got %s: %v", r.Path, r.Status, r.Err)
+		}
+	}
+}
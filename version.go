@@ -0,0 +1,138 @@
+package papertool
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+/*
+ * Per-version detail from the Fill API:
+ *
+ *   GET https://fill.papermc.io/v3/projects/${PROJECT}/versions/${VERSION}
+ *   {
+ *     "version": {
+ *       "id": "1.21.4",
+ *       "support": { "status": "SUPPORTED", "end": "2026-01-01" },
+ *       "java": {
+ *         "version": { "minimum": 21 },
+ *         "flags": { "recommended": ["-XX:+AlwaysPreTouch", ...] }
+ *       }
+ *     },
+ *     "builds": [232, 231, ...]
+ *   }
+ */
+
+const (
+	SupportSupported   = "SUPPORTED"
+	SupportDeprecated  = "DEPRECATED"
+	SupportUnsupported = "UNSUPPORTED"
+)
+
+// Version is the detail for a single version of a project.
+type Version struct {
+	ProjectID     string
+	ID            string
+	SupportStatus string    // one of the Support* constants
+	SupportEnd    time.Time // zero if no end date has been announced
+	JavaMinimum   int       // minimum Java feature release, 0 if unknown
+	JavaFlags     []string  // recommended JVM flags
+	Builds        []int     // oldest first
+	raw           []byte
+}
+
+type v3Version struct {
+	Version struct {
+		ID      string `json:"id"`
+		Support struct {
+			Status string `json:"status"`
+			End    string `json:"end"`
+		} `json:"support"`
+		Java struct {
+			Version struct {
+				Minimum int `json:"minimum"`
+			} `json:"version"`
+			Flags struct {
+				Recommended []string `json:"recommended"`
+			} `json:"flags"`
+		} `json:"java"`
+	} `json:"version"`
+	Builds []int `json:"builds"`
+}
+
+func GetVersion(src *url.URL, project string, version string) (*Version, error) {
+	u := fmt.Sprintf("%s/v3/projects/%s/versions/%s", src.String(), project, version)
+
+	v3 := &v3Version{}
+	raw, err := fetch(u, v3)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Version{
+		ProjectID:     project,
+		ID:            v3.Version.ID,
+		SupportStatus: v3.Version.Support.Status,
+		JavaMinimum:   v3.Version.Java.Version.Minimum,
+		JavaFlags:     v3.Version.Java.Flags.Recommended,
+		raw:           raw,
+	}
+
+	if v3.Version.Support.End != "" {
+		v.SupportEnd, err = parseDate(v3.Version.Support.End)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: support end: %w", project, version, err)
+		}
+	}
+
+	// Builds come newest-first; reverse to match GetBuilds.
+	for i := len(v3.Builds) - 1; i >= 0; i-- {
+		v.Builds = append(v.Builds, v3.Builds[i])
+	}
+
+	return v, nil
+}
+
+// parseDate accepts either a full RFC3339 timestamp or a bare date.
+func parseDate(s string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err == nil {
+		return t, nil
+	}
+
+	return ParseTime(s)
+}
+
+// Supported reports whether the version is still receiving updates.
+func (v *Version) Supported() bool {
+	return v.SupportStatus == SupportSupported
+}
+
+// EndOfLife reports whether the version is unsupported, or past its
+// announced end of support as of now.
+func (v *Version) EndOfLife(now time.Time) bool {
+	if v.SupportStatus == SupportUnsupported {
+		return true
+	}
+
+	return !v.SupportEnd.IsZero() && now.After(v.SupportEnd)
+}
+
+// SupportWarning returns a sentence describing why the version shouldn't
+// be deployed as of now, or "" if it's fine.
+func (v *Version) SupportWarning(now time.Time) string {
+	switch {
+	case v.EndOfLife(now):
+		return fmt.Sprintf("%s %s is end-of-life and no longer receives updates", v.ProjectID, v.ID)
+	case v.SupportStatus == SupportDeprecated && !v.SupportEnd.IsZero():
+		return fmt.Sprintf("%s %s is deprecated, support ends %s", v.ProjectID, v.ID, v.SupportEnd.Format("2006-01-02"))
+	case v.SupportStatus == SupportDeprecated:
+		return fmt.Sprintf("%s %s is deprecated", v.ProjectID, v.ID)
+	}
+
+	return ""
+}
+
+func (v *Version) Raw() []byte {
+	return v.raw
+}
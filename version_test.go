@@ -0,0 +1,37 @@
+package papertool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSupportWarning(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	past := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	future := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		status string
+		end    time.Time
+		eol    bool
+		want   string
+	}{
+		{SupportSupported, time.Time{}, false, ""},
+		{SupportSupported, future, false, ""},
+		{SupportUnsupported, time.Time{}, true, "paper 1.20.4 is end-of-life and no longer receives updates"},
+		{SupportDeprecated, future, false, "paper 1.20.4 is deprecated, support ends 2026-12-01"},
+		{SupportDeprecated, past, true, "paper 1.20.4 is end-of-life and no longer receives updates"},
+		{SupportDeprecated, time.Time{}, false, "paper 1.20.4 is deprecated"},
+		{SupportDeprecated, now, false, "paper 1.20.4 is deprecated, support ends 2026-05-10"},
+	}
+
+	for _, test := range tests {
+		v := &Version{ProjectID: "paper", ID: "1.20.4", SupportStatus: test.status, SupportEnd: test.end}
+		if got := v.EndOfLife(now); got != test.eol {
+			t.Fatalf("%s %v: expected end-of-life %v got %v", test.status, test.end, test.eol, got)
+		}
+		if got := v.SupportWarning(now); got != test.want {
+			t.Fatalf("%s %v: expected %q got %q", test.status, test.end, test.want, got)
+		}
+	}
+}